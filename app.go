@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,54 +16,68 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jakeasaurus/lazytodo/todotxt"
 )
 
-// Styles using Lip Gloss
+// Styles using Lip Gloss. Their colors come from the theme, see theme.go.
 var (
 	appStyle = lipgloss.NewStyle().
 			Padding(0, 1)
 
 	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
 			Padding(0, 1)
 
 	statusMessageStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
 
 	todoListStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#874BFD")).
 			Padding(0, 1)
 
 	detailsStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#F25D94")).
 			Padding(0, 1)
 
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262"))
+	helpStyle = lipgloss.NewStyle()
 
 	inputStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#FF7CCB")).
 			Padding(1).
 			Width(50)
 
+	// selectedStyle colors the selected todo in the list
+	selectedStyle = lipgloss.NewStyle()
+
 	completedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#626262")).
 			Strikethrough(true)
 
+	doneStyle = lipgloss.NewStyle()
+
+	pendingStyle = lipgloss.NewStyle()
+
 	priorityStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF5F87")).
 			Bold(true)
 
-	projectStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#5FAFFF"))
+	projectStyle = lipgloss.NewStyle()
+
+	contextStyle = lipgloss.NewStyle()
+
+	tagStyle = lipgloss.NewStyle()
+
+	overdueStyle = lipgloss.NewStyle().
+			Bold(true)
+
+	dueTodayStyle = lipgloss.NewStyle().
+			Bold(true)
+
+	dueSoonStyle = lipgloss.NewStyle()
 
-	contextStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFAF5F"))
+	futureStyle = lipgloss.NewStyle().
+			Faint(true)
+
+	matchStyle = lipgloss.NewStyle().
+			Bold(true).
+			Underline(true)
 )
 
 // InputMode represents the current input mode
@@ -70,8 +88,34 @@ const (
 	ModeAdd
 	ModeEdit
 	ModeFilter
+	// ModeConflict asks how to resolve a save that clashed with changes
+	// made to the todo file on disk
+	ModeConflict
+	// ModeViews picks a saved view in the command window
+	ModeViews
 )
 
+// tuiLockTimeout is how long a change in the TUI waits for another process
+// to release the todo file
+const tuiLockTimeout = 300 * time.Millisecond
+
+// fileCheckMsg asks the model to check the todo file for outside changes
+type fileCheckMsg struct{}
+
+// watchFile waits for the store to signal a possible outside change. A
+// store that can't be watched leaves live reload off.
+func watchFile(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return fileCheckMsg{}
+	}
+}
+
 // Cursor control functions
 func hideCursor() {
 	fmt.Fprint(os.Stderr, "\033[?25l")
@@ -81,14 +125,40 @@ func showCursor() {
 	fmt.Fprint(os.Stderr, "\033[?25h")
 }
 
+// dueSoonDays is how far ahead a due date counts as "due soon"
+var dueSoonDays = 3
+
+// dueStyle picks the style for a todo's due date, if it needs one
+func dueStyle(todo todotxt.Todo, now time.Time) (lipgloss.Style, bool) {
+	due, ok := todo.DueDate()
+	if !ok || todo.Completed {
+		return lipgloss.Style{}, false
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch days := int(math.Round(due.Sub(today).Hours() / 24)); {
+	case days < 0:
+		return overdueStyle, true
+	case days == 0:
+		return dueTodayStyle, true
+	case days <= dueSoonDays:
+		return dueSoonStyle, true
+	}
+	return lipgloss.Style{}, false
+}
+
 // TodoItem represents a todo item for the list component
 type TodoItem struct {
-	todo Todo
+	todo todotxt.Todo
+	// matches are the byte indexes of the runes of the text the filter
+	// matched, for highlighting
+	matches []int
 }
 
 func (i TodoItem) FilterValue() string { return i.todo.Text }
 func (i TodoItem) Title() string {
-	title := i.todo.Text
+	text := highlightMatches(i.todo.Text, i.matches)
+	title := text
 
 	// Add priority
 	if i.todo.Priority != "" {
@@ -110,6 +180,17 @@ func (i TodoItem) Title() string {
 		title = strings.ReplaceAll(title, "@"+context, contextStyle.Render("@"+context))
 	}
 
+	// Flag overdue, due-today and due-soon items
+	if style, ok := dueStyle(i.todo, time.Now()); ok {
+		dueTag := "due:" + i.todo.Tags["due"]
+		title = strings.ReplaceAll(title, dueTag, style.Render(dueTag))
+	}
+
+	// Dim todos that are only shown because future todos are revealed
+	if i.todo.IsFuture(time.Now()) {
+		title = futureStyle.Render("⏸ " + text)
+	}
+
 	return title
 }
 
@@ -137,40 +218,84 @@ func (i TodoItem) Description() string {
 
 // Model represents the application state
 type Model struct {
-	todoManager *TodoManager
-	list        list.Model
-	textInput   textinput.Model
-	help        help.Model
-	inputMode   InputMode
-	showHelp    bool
-	statusMsg   string
-	width       int
-	height      int
+	todoManager *todotxt.TodoManager
+	// changes signals when the todo file may have changed on disk
+	changes   <-chan struct{}
+	list      list.Model
+	textInput textinput.Model
+	help      help.Model
+	inputMode InputMode
+	showHelp  bool
+	statusMsg string
+	width     int
+	height    int
+	// editID is the todo being edited, so an outside change to the list
+	// can't redirect the edit to another todo
+	editID int
+	// reloadPending is set when the todo file changed on disk while a
+	// todo was being added or edited; the reload waits until after
+	reloadPending bool
 	// Custom filtering
 	isFiltering bool
 	filterInput textinput.Model
-	filterText  string
+	// filter is the last filter that parsed; filterErr says why the text
+	// being typed doesn't. filterMode is the match mode being typed in.
+	filter     textFilter
+	filterErr  error
+	filterMode matchMode
+	// Todos with a t: threshold in the future are hidden unless revealed
+	showFuture bool
+	settings   Settings
+	// completion offers completions while adding or editing a todo
+	completion completion
+	// grouping gathers the todos under headers; collapsed holds the
+	// groups showing only their header
+	grouping  grouping
+	collapsed map[string]bool
+	// focus is the pane keys go to. sidebarFilter is the project or
+	// context picked in the sidebar, empty for all todos, and
+	// detailsOffset is how far the details pane is scrolled.
+	focus         pane
+	sidebarFilter string
+	detailsOffset int
+	// view is the saved view being shown, if any; viewCursor is the
+	// view picker's selection, where 0 is all todos
+	view       ViewSettings
+	viewCursor int
 }
 
 // Key bindings
 type keyMap struct {
-	Up        key.Binding
-	Down      key.Binding
-	Add       key.Binding
-	Edit      key.Binding
-	Delete    key.Binding
-	Toggle    key.Binding
-	Help      key.Binding
-	Quit      key.Binding
-	Refresh   key.Binding
-	Enter     key.Binding
-	Escape    key.Binding
-	Home      key.Binding
-	End       key.Binding
-	PriorityA key.Binding
-	PriorityB key.Binding
-	PriorityC key.Binding
-	Filter    key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	Add          key.Binding
+	Edit         key.Binding
+	Delete       key.Binding
+	Toggle       key.Binding
+	Archive      key.Binding
+	Undo         key.Binding
+	Redo         key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+	Refresh      key.Binding
+	Enter        key.Binding
+	Escape       key.Binding
+	Home         key.Binding
+	End          key.Binding
+	PriorityA    key.Binding
+	PriorityB    key.Binding
+	PriorityC    key.Binding
+	Filter       key.Binding
+	Sort         key.Binding
+	Future       key.Binding
+	Views        key.Binding
+	MatchMode    key.Binding
+	Complete     key.Binding
+	CompletePrev key.Binding
+	Collapse     key.Binding
+	Group        key.Binding
+	FocusNext    key.Binding
+	FocusPrev    key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -179,10 +304,10 @@ func (k keyMap) ShortHelp() []key.Binding {
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Home, k.End},
-		{k.Add, k.Edit, k.Delete, k.Toggle},
+		{k.Up, k.Down, k.Home, k.End, k.FocusNext, k.FocusPrev},
+		{k.Add, k.Edit, k.Delete, k.Toggle, k.Archive, k.Undo, k.Redo},
 		{k.PriorityA, k.PriorityB, k.PriorityC},
-		{k.Filter, k.Refresh, k.Help, k.Quit},
+		{k.Filter, k.Views, k.Sort, k.Group, k.Collapse, k.Future, k.Refresh, k.Help, k.Quit},
 	}
 }
 
@@ -211,13 +336,25 @@ var keys = keyMap{
 		key.WithKeys("x", " "),
 		key.WithHelp("x/space", "toggle complete"),
 	),
+	Archive: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "archive completed"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
 	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
-		key.WithHelp("q", "quit"),
+		key.WithHelp("q/ctrl+c", "quit"),
 	),
 	Refresh: key.NewBinding(
 		key.WithKeys("r"),
@@ -255,37 +392,180 @@ var keys = keyMap{
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter todos"),
 	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "cycle sort order"),
+	),
+	Future: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "show/hide future todos"),
+	),
+	Views: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "pick a saved view"),
+	),
+	MatchMode: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "switch match mode"),
+	),
+	Complete: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "complete +project, @context or tag"),
+	),
+	CompletePrev: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous completion"),
+	),
+	Collapse: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "collapse/expand group"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "cycle grouping"),
+	),
+	FocusNext: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "focus next pane"),
+	),
+	FocusPrev: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "focus previous pane"),
+	),
+}
+
+// keyAction is a key map binding with the name the [keys] section of the
+// settings file knows it by
+type keyAction struct {
+	name    string
+	binding *key.Binding
+}
+
+// actions lists the key map's bindings in help order
+func (k *keyMap) actions() []keyAction {
+	return []keyAction{
+		{"up", &k.Up},
+		{"down", &k.Down},
+		{"home", &k.Home},
+		{"end", &k.End},
+		{"focus_next", &k.FocusNext},
+		{"focus_previous", &k.FocusPrev},
+		{"add", &k.Add},
+		{"edit", &k.Edit},
+		{"delete", &k.Delete},
+		{"toggle", &k.Toggle},
+		{"archive", &k.Archive},
+		{"undo", &k.Undo},
+		{"redo", &k.Redo},
+		{"priority_a", &k.PriorityA},
+		{"priority_b", &k.PriorityB},
+		{"priority_c", &k.PriorityC},
+		{"filter", &k.Filter},
+		{"views", &k.Views},
+		{"sort", &k.Sort},
+		{"group", &k.Group},
+		{"collapse", &k.Collapse},
+		{"future", &k.Future},
+		{"refresh", &k.Refresh},
+		{"help", &k.Help},
+		{"quit", &k.Quit},
+		{"enter", &k.Enter},
+		{"escape", &k.Escape},
+		{"match_mode", &k.MatchMode},
+		{"complete", &k.Complete},
+		{"complete_previous", &k.CompletePrev},
+	}
+}
+
+// inputActions are the bindings used while typing into the command
+// window. Every other binding only applies in normal mode, so the two
+// groups can share keys.
+var inputActions = map[string]bool{
+	"enter":             true,
+	"escape":            true,
+	"match_mode":        true,
+	"complete":          true,
+	"complete_previous": true,
+}
+
+// keyLabel renders keys for help text, such as "x/space"
+func keyLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			labels[i] = "space"
+		case "up":
+			labels[i] = "↑"
+		case "down":
+			labels[i] = "↓"
+		default:
+			labels[i] = k
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// helpKey returns the key shown for a binding in hints and help
+func helpKey(b key.Binding) string {
+	return b.Help().Key
 }
 
 // Initialize the model
-func initialModel() Model {
-	// Create todo manager
-	tm := NewTodoManager()
+func initialModel(tm *todotxt.TodoManager, settings Settings) Model {
+	// Don't freeze the UI waiting for another process to finish saving
+	tm.SetLockTimeout(tuiLockTimeout)
+	tm.SetSortMode(sortModes[settings.Sort.Default])
+	settings.apply()
+
+	// Watch the todo file for the lifetime of the program
+	changes, _ := tm.Watch(context.Background())
 
 	// Create list model
 	items := []list.Item{}
+	now := time.Now()
 	for _, todo := range tm.GetTodos() {
+		if !settings.UI.ShowFuture && todo.IsFuture(now) {
+			continue
+		}
 		items = append(items, TodoItem{todo: todo})
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "📋 Todo List"
+	delegate := list.NewDefaultDelegate()
+	selected := selectedStyle.GetForeground()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(selected).BorderLeftForeground(selected)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(selected).BorderLeftForeground(selected)
+	if monochrome {
+		delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.Foreground(lipgloss.NoColor{})
+		delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.Foreground(lipgloss.NoColor{}).Faint(true)
+	}
+
+	l := list.New(items, delegate, 0, 0)
+	l.Title = settings.UI.Title
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(false) // Disable built-in filtering, we'll implement our own
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = helpStyle
 	l.Styles.HelpStyle = helpStyle
+	// Keep the list's own navigation in step with remapped keys
+	l.KeyMap.CursorUp = keys.Up
+	l.KeyMap.CursorDown = keys.Down
+	l.KeyMap.GoToStart = keys.Home
+	l.KeyMap.GoToEnd = keys.End
+	l.KeyMap.Quit = keys.Quit
+	l.KeyMap.ShowFullHelp.SetEnabled(false)
+	l.KeyMap.CloseFullHelp.SetEnabled(false)
 
 	// Create text input
 	ti := textinput.New()
 	ti.Placeholder = "Enter your todo..."
 	ti.Blur() // Don't focus initially - only focus when in input mode
-	ti.CharLimit = 200
+	ti.CharLimit = settings.UI.CharLimit
 	ti.Width = 50
 
 	// Create filter input
 	fi := textinput.New()
-	fi.Placeholder = "Type to filter todos... (ESC to clear)"
+	fi.Placeholder = fmt.Sprintf("+project @context pri:A due<=today -done... (%s to clear)", helpKey(keys.Escape))
 	fi.Blur()
 	fi.CharLimit = 100
 	fi.Width = 70 // Make it wider
@@ -293,8 +573,12 @@ func initialModel() Model {
 	// Create help
 	h := help.New()
 
-	return Model{
+	filterMode, _ := parseMatchMode(settings.UI.FilterMode)
+	group, _ := parseGrouping(settings.UI.Group)
+
+	m := Model{
 		todoManager: tm,
+		changes:     changes,
 		list:        l,
 		textInput:   ti,
 		help:        h,
@@ -303,13 +587,22 @@ func initialModel() Model {
 		// Custom filtering
 		isFiltering: false,
 		filterInput: fi,
-		filterText:  "",
+		filter:      textFilter{mode: filterMode},
+		filterMode:  filterMode,
+		showFuture:  settings.UI.ShowFuture,
+		settings:    settings,
+		grouping:    group,
+		collapsed:   map[string]bool{},
 	}
+	if group != groupNone {
+		m.refreshList()
+	}
+	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return nil
+	return watchFile(m.changes)
 }
 
 // Update handles messages
@@ -318,12 +611,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case fileCheckMsg:
+		// Pick up edits made by todo.sh, editors or sync tools, unless a
+		// conflict is waiting to be resolved. Adding or editing holds the
+		// reload back so the list doesn't shift under the input.
+		switch m.inputMode {
+		case ModeConflict:
+		case ModeAdd, ModeEdit:
+			m.reloadPending = true
+		default:
+			m.reloadChanged()
+		}
+		return m, watchFile(m.changes)
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 
 		// Adjust layout for small terminals
-		if msg.Height < 20 {
+		if m.compact() {
 			// Very small terminal - use most of the space for the list
 			listWidth := msg.Width - 10
 			listHeight := msg.Height - 8
@@ -333,7 +639,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.list.SetSize(listWidth, listHeight)
 		} else {
 			// Normal terminal size - use column layout
-			listWidth := msg.Width*3/5 - 4
+			listWidth := m.listWidth() - 4
 			listHeight := msg.Height - 12 // Account for command window, status, help
 			if listHeight < 5 {
 				listHeight = 5
@@ -343,8 +649,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Update text inputs width based on screen width
 		inputWidth := msg.Width - 20 // Leave some margin
-		if inputWidth > 80 {
-			inputWidth = 80
+		if inputWidth > m.settings.Layout.MaxInputWidth {
+			inputWidth = m.settings.Layout.MaxInputWidth
 		}
 		if inputWidth < 30 {
 			inputWidth = 30
@@ -353,8 +659,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.filterInput.Width = inputWidth
 
 		// Update details panel width (only for normal sized terminals)
-		if msg.Height >= 20 {
-			detailsWidth := msg.Width*2/5 - 6 // Remaining space minus margins
+		if !m.compact() {
+			detailsWidth := msg.Width - m.sidebarWidth() - m.listWidth() - 6 // Remaining space minus margins
 			if detailsWidth < 30 {
 				detailsWidth = 30
 			}
@@ -367,48 +673,110 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// Resolve a save conflict before anything else. Quitting leaves
+		// todo.txt as it is on disk, and cancelling drops the change.
+		if m.inputMode == ModeConflict {
+			switch {
+			case msg.String() == "o":
+				m.inputMode = ModeNormal
+				m.applyChange(m.todoManager.ForceSave(), "Overwrote todo.txt with your changes")
+			case msg.String() == "r":
+				m.inputMode = ModeNormal
+				m.applyChange(m.todoManager.Load(), "Reloaded todo.txt from disk")
+			case key.Matches(msg, keys.Escape):
+				m.inputMode = ModeNormal
+				m.applyChange(m.todoManager.Load(), "Cancelled your change, reloaded todo.txt from disk")
+			case key.Matches(msg, keys.Quit):
+				hideCursor()
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// Pick a view by number, or move through the picker
+		if m.inputMode == ModeViews {
+			if n, ok := viewNumber(msg.String()); ok {
+				m.pickView(n)
+				return m, nil
+			}
+			switch {
+			case key.Matches(msg, keys.Up):
+				m.viewCursor = max(m.viewCursor-1, 0)
+			case key.Matches(msg, keys.Down):
+				m.viewCursor = min(m.viewCursor+1, len(m.settings.Views))
+			case key.Matches(msg, keys.Enter):
+				m.pickView(m.viewCursor)
+			case key.Matches(msg, keys.Escape, keys.Views, keys.Quit):
+				m.inputMode = ModeNormal
+			}
+			return m, nil
+		}
+
 		// Handle input mode
 		if m.inputMode != ModeNormal {
-			switch msg.String() {
-			case "enter":
+			switch {
+			case key.Matches(msg, keys.Enter):
 				switch m.inputMode {
 				case ModeAdd, ModeEdit:
 					// Submit input for add/edit
+					mode := m.inputMode
 					input := strings.TrimSpace(m.textInput.Value())
-					if input != "" {
-						switch m.inputMode {
-						case ModeAdd:
-							m.todoManager.AddTodo(input)
-							m.statusMsg = statusMessageStyle("Added: " + input)
-						case ModeEdit:
-							if item, ok := m.list.SelectedItem().(TodoItem); ok {
-								m.todoManager.UpdateTodo(item.todo.ID, input)
-								m.statusMsg = statusMessageStyle("Updated todo")
-							}
-						}
-						m.refreshList()
-					}
 					m.inputMode = ModeNormal
 					m.textInput.SetValue("")
 					hideCursor()
+					// The todo manager refuses input that is empty once
+					// sanitized, and the status line says so
+					switch mode {
+					case ModeAdd:
+						m.applyChange(m.todoManager.AddTodo(input), "Added: "+input)
+					case ModeEdit:
+						m.applyChange(m.todoManager.UpdateTodo(m.editID, input), "Updated todo")
+					}
+					m.reloadDeferred()
 				case ModeFilter:
 					// Exit filter mode but keep filter active
 					m.inputMode = ModeNormal
 					m.isFiltering = false
+					if m.filterErr != nil {
+						m.statusMsg = statusMessageStyle("⚠ " + m.filterErr.Error() + ", keeping the last valid filter")
+						m.filterErr = nil
+						m.filterMode = m.filter.mode
+						m.filterInput.SetValue(m.filter.text)
+					}
 					hideCursor()
 				}
 				return m, nil
 
-			case "esc":
+			case (m.inputMode == ModeAdd || m.inputMode == ModeEdit) && key.Matches(msg, keys.Complete, keys.CompletePrev):
+				step := 1
+				if key.Matches(msg, keys.CompletePrev) {
+					step = -1
+				}
+				if value, pos, ok := m.completion.cycle(m.textInput.Value(), step); ok {
+					m.textInput.SetValue(value)
+					m.textInput.SetCursor(pos)
+				}
+				return m, nil
+
+			case m.inputMode == ModeFilter && key.Matches(msg, keys.MatchMode):
+				m.filterMode = m.filterMode.next()
+				m.setFilter(m.filterInput.Value())
+				m.leaveChangedView()
+				m.refreshList()
+				return m, nil
+
+			case key.Matches(msg, keys.Escape):
 				switch m.inputMode {
 				case ModeAdd, ModeEdit:
 					m.inputMode = ModeNormal
 					m.textInput.SetValue("")
+					m.reloadDeferred()
 				case ModeFilter:
 					// Clear filter and exit filter mode
 					m.inputMode = ModeNormal
 					m.isFiltering = false
-					m.filterText = ""
+					m.setFilter("")
+					m.setView(ViewSettings{})
 					m.filterInput.SetValue("")
 					m.refreshList()
 					m.statusMsg = statusMessageStyle("Filter cleared")
@@ -419,10 +787,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch m.inputMode {
 				case ModeAdd, ModeEdit:
 					m.textInput, cmd = m.textInput.Update(msg)
+					m.completion.update(m.textInput.Value(), m.textInput.Position())
 				case ModeFilter:
 					m.filterInput, cmd = m.filterInput.Update(msg)
 					// Update filter in real-time
-					m.filterText = m.filterInput.Value()
+					m.setFilter(m.filterInput.Value())
+					m.leaveChangedView()
 					m.refreshList()
 				}
 				return m, cmd
@@ -431,14 +801,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle help mode
 		if m.showHelp {
-			switch msg.String() {
-			case "?", "q", "esc":
+			if key.Matches(msg, keys.Help, keys.Quit, keys.Escape) {
 				m.showHelp = false
-				return m, nil
 			}
 			return m, nil
 		}
 
+		// alt+1 to alt+9 switch straight to a saved view, alt+0 back to all
+		if n, ok := viewNumber(strings.TrimPrefix(msg.String(), "alt+")); ok && msg.Alt {
+			m.pickView(n)
+			return m, nil
+		}
+
+		// Tab moves between the sidebar, list and details panes, and the
+		// movement keys move within the pane that has focus
+		switch {
+		case key.Matches(msg, keys.FocusNext):
+			m.cycleFocus(1)
+			return m, nil
+		case key.Matches(msg, keys.FocusPrev):
+			m.cycleFocus(-1)
+			return m, nil
+		}
+		switch m.focus {
+		case paneSidebar:
+			switch {
+			case key.Matches(msg, keys.Up):
+				m.moveSidebar(-1)
+				return m, nil
+			case key.Matches(msg, keys.Down):
+				m.moveSidebar(1)
+				return m, nil
+			case key.Matches(msg, keys.Home):
+				m.pickSidebar(sidebarEntries(m.todoManager.GetTodos()), 0)
+				return m, nil
+			case key.Matches(msg, keys.End):
+				entries := sidebarEntries(m.todoManager.GetTodos())
+				m.pickSidebar(entries, len(entries)-1)
+				return m, nil
+			case key.Matches(msg, keys.Enter):
+				m.focus = paneList
+				return m, nil
+			}
+		case paneDetails:
+			switch {
+			case key.Matches(msg, keys.Up):
+				m.detailsOffset = max(m.detailsOffset-1, 0)
+				return m, nil
+			case key.Matches(msg, keys.Down):
+				m.detailsOffset = min(m.detailsOffset+1, strings.Count(m.detailsContent(), "\n"))
+				return m, nil
+			case key.Matches(msg, keys.Home):
+				m.detailsOffset = 0
+				return m, nil
+			}
+		}
+
 		// Handle normal mode keys
 		switch {
 		case key.Matches(msg, keys.Quit):
@@ -450,43 +868,63 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textInput.Placeholder = "Enter new todo..."
 			m.textInput.SetValue("")
 			m.textInput.Focus()
+			m.completion = newCompletion(m.todoManager)
 			showCursor()
 			return m, nil
 
 		case key.Matches(msg, keys.Edit):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
 				m.inputMode = ModeEdit
+				m.editID = item.todo.ID
 				m.textInput.Placeholder = "Edit todo..."
 				m.textInput.SetValue(item.todo.Text)
 				m.textInput.Focus()
+				m.completion = newCompletion(m.todoManager)
 				showCursor()
 			}
 			return m, nil
 
 		case key.Matches(msg, keys.Delete):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
-				m.todoManager.DeleteTodo(item.todo.ID)
-				m.statusMsg = statusMessageStyle("Deleted todo")
-				m.refreshList()
+				m.applyChange(m.todoManager.DeleteTodo(item.todo.ID), "Deleted todo")
 			}
 			return m, nil
 
 		case key.Matches(msg, keys.Toggle):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
-				m.todoManager.ToggleComplete(item.todo.ID)
 				status := "completed"
 				if item.todo.Completed {
 					status = "uncompleted"
 				}
-				m.statusMsg = statusMessageStyle("Todo " + status)
-				m.refreshList()
+				m.applyChange(m.todoManager.ToggleComplete(item.todo.ID), "Todo "+status)
 			}
 			return m, nil
 
+		case key.Matches(msg, keys.Archive):
+			count, err := m.todoManager.Archive()
+			m.applyChange(err, fmt.Sprintf("Archived %d completed todos", count))
+			return m, nil
+
+		case key.Matches(msg, keys.Undo):
+			label, err := m.todoManager.Undo()
+			if errors.Is(err, todotxt.ErrNothingToUndo) {
+				m.statusMsg = statusMessageStyle("Nothing to undo")
+				return m, nil
+			}
+			m.applyChange(err, "Undid "+label)
+			return m, nil
+
+		case key.Matches(msg, keys.Redo):
+			label, err := m.todoManager.Redo()
+			if errors.Is(err, todotxt.ErrNothingToRedo) {
+				m.statusMsg = statusMessageStyle("Nothing to redo")
+				return m, nil
+			}
+			m.applyChange(err, "Redid "+label)
+			return m, nil
+
 		case key.Matches(msg, keys.Refresh):
-			m.todoManager.Load()
-			m.refreshList()
-			m.statusMsg = statusMessageStyle("Refreshed from file")
+			m.applyChange(m.todoManager.Load(), "Refreshed from file")
 			return m, nil
 
 		case key.Matches(msg, keys.Help):
@@ -503,26 +941,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, keys.PriorityA):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
-				m.todoManager.SetPriority(item.todo.ID, "A")
-				m.statusMsg = statusMessageStyle("Set priority to A")
-				m.refreshList()
+				m.applyChange(m.todoManager.SetPriority(item.todo.ID, "A"), "Set priority to A")
 			}
 			return m, nil
 
 		case key.Matches(msg, keys.PriorityB):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
-				m.todoManager.SetPriority(item.todo.ID, "B")
-				m.statusMsg = statusMessageStyle("Set priority to B")
-				m.refreshList()
+				m.applyChange(m.todoManager.SetPriority(item.todo.ID, "B"), "Set priority to B")
 			}
 			return m, nil
 
 		case key.Matches(msg, keys.PriorityC):
 			if item, ok := m.list.SelectedItem().(TodoItem); ok {
-				m.todoManager.SetPriority(item.todo.ID, "C")
-				m.statusMsg = statusMessageStyle("Set priority to C")
+				m.applyChange(m.todoManager.SetPriority(item.todo.ID, "C"), "Set priority to C")
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Sort):
+			switch m.todoManager.SortMode() {
+			case todotxt.SortPriority:
+				m.todoManager.SetSortMode(todotxt.SortDue)
+			case todotxt.SortDue:
+				m.todoManager.SetSortMode(todotxt.SortFile)
+			default:
+				m.todoManager.SetSortMode(todotxt.SortPriority)
+			}
+			m.statusMsg = statusMessageStyle("Sorted by " + m.todoManager.SortMode().String())
+			m.refreshList()
+			return m, nil
+
+		case key.Matches(msg, keys.Group):
+			m.grouping = m.grouping.next()
+			if m.grouping == groupNone {
+				m.statusMsg = statusMessageStyle("Not grouped")
+			} else {
+				m.statusMsg = statusMessageStyle("Grouped by " + m.grouping.String())
+			}
+			m.refreshList()
+			m.list.Select(0)
+			return m, nil
+
+		case key.Matches(msg, keys.Collapse):
+			if name, ok := m.selectedGroup(); ok {
+				m.collapsed[name] = !m.collapsed[name]
 				m.refreshList()
+				m.selectGroup(name)
+			}
+			return m, nil
+
+		case key.Matches(msg, keys.Future):
+			m.showFuture = !m.showFuture
+			if m.showFuture {
+				m.statusMsg = statusMessageStyle("Showing future todos")
+			} else {
+				m.statusMsg = statusMessageStyle("Hiding future todos")
 			}
+			m.refreshList()
 			return m, nil
 
 		case key.Matches(msg, keys.Filter):
@@ -530,10 +1004,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.isFiltering = true
 			m.filterInput.Focus()
 			// Set current filter text if any, otherwise empty
-			m.filterInput.SetValue(m.filterText)
+			m.filterInput.SetValue(m.filter.text)
 			showCursor()
 			m.statusMsg = statusMessageStyle("Filter mode active - type to search")
 			return m, nil
+
+		case key.Matches(msg, keys.Views):
+			if len(m.settings.Views) == 0 {
+				m.statusMsg = statusMessageStyle("No saved views yet, add [[views]] to " + settingsPath())
+				return m, nil
+			}
+			m.inputMode = ModeViews
+			m.viewCursor = 0
+			for i, view := range m.settings.Views {
+				if view.Name == m.view.Name {
+					m.viewCursor = i + 1
+				}
+			}
+			return m, nil
 		}
 
 		// Update list
@@ -544,20 +1032,265 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// detailsContent returns what the details pane shows about the selected
+// todo
+func (m Model) detailsContent() string {
+	if item, ok := m.list.SelectedItem().(TodoItem); ok {
+		todo := item.todo
+		return lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Bold(true).Render("📝 Todo Details"),
+			"",
+			fmt.Sprintf("ID: %d", todo.ID),
+			fmt.Sprintf("Status: %s", func() string {
+				if todo.Completed {
+					return doneStyle.Render("✓ Completed")
+				}
+				return pendingStyle.Render("○ Pending")
+			}()),
+			func() string {
+				if todo.Priority != "" {
+					return fmt.Sprintf("Priority: %s", priorityStyle.Render("("+todo.Priority+")"))
+				}
+				return ""
+			}(),
+			func() string {
+				if todo.CreatedDate != "" {
+					return fmt.Sprintf("Created: %s", todo.CreatedDate)
+				}
+				return ""
+			}(),
+			func() string {
+				if todo.CompletionDate != "" {
+					return fmt.Sprintf("Completed: %s", todo.CompletionDate)
+				}
+				return ""
+			}(),
+			func() string {
+				if len(todo.Projects) > 0 {
+					return fmt.Sprintf("Projects: %s", projectStyle.Render(strings.Join(todo.Projects, ", ")))
+				}
+				return ""
+			}(),
+			func() string {
+				if len(todo.Contexts) > 0 {
+					return fmt.Sprintf("Contexts: %s", contextStyle.Render(strings.Join(todo.Contexts, ", ")))
+				}
+				return ""
+			}(),
+			func() string {
+				if len(todo.Tags) == 0 {
+					return ""
+				}
+				tagKeys := make([]string, 0, len(todo.Tags))
+				for k := range todo.Tags {
+					tagKeys = append(tagKeys, k)
+				}
+				sort.Strings(tagKeys)
+				lines := []string{"Tags:"}
+				for _, k := range tagKeys {
+					lines = append(lines, fmt.Sprintf("  %s: %s", k, tagStyle.Render(todo.Tags[k])))
+				}
+				return strings.Join(lines, "\n")
+			}(),
+			"",
+			helpStyle.Render("Raw: "+todo.Raw),
+		)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.NewStyle().Bold(true).Render("📝 Todo Details"),
+		"",
+		helpStyle.Render("Select a todo to view details"),
+	)
+}
+
+// compact reports whether the terminal is too short for the details panel
+func (m Model) compact() bool {
+	return m.height < m.settings.Layout.CompactHeight
+}
+
+// listWidth returns the width of the todo list column, details panel aside
+func (m Model) listWidth() int {
+	return int(float64(m.width-m.sidebarWidth()) * m.settings.Layout.ListWidth)
+}
+
+// reloadChanged reloads the todo list if it changed on disk
+func (m *Model) reloadChanged() {
+	if changed, err := m.todoManager.ChangedOnDisk(); err == nil && changed {
+		if err := m.todoManager.Load(); err == nil {
+			m.refreshList()
+			m.statusMsg = statusMessageStyle("Reloaded: todo.txt changed on disk")
+		}
+	}
+}
+
+// reloadDeferred catches up on a reload held back while adding or editing,
+// unless the change just made ran into a conflict that must be resolved
+// first
+func (m *Model) reloadDeferred() {
+	if m.reloadPending && m.inputMode == ModeNormal {
+		m.reloadPending = false
+		m.reloadChanged()
+	}
+}
+
+// applyChange reports the outcome of a TodoManager change on the status
+// line, switching to the conflict prompt when a save couldn't be merged
+func (m *Model) applyChange(err error, success string) {
+	switch {
+	case errors.Is(err, todotxt.ErrConflict):
+		m.inputMode = ModeConflict
+		m.statusMsg = statusMessageStyle("⚠ " + err.Error())
+	case errors.Is(err, todotxt.ErrLocked):
+		m.statusMsg = statusMessageStyle("🔒 Locked by another process, nothing was changed. Try again in a moment")
+	case err != nil:
+		m.statusMsg = statusMessageStyle("Error: " + err.Error())
+	default:
+		m.statusMsg = statusMessageStyle(success)
+	}
+	m.refreshList()
+}
+
+// setFilter makes text the filter, matched in the current match mode.
+// While it doesn't parse, the last filter that did stays in effect and
+// filterErr says what is wrong.
+func (m *Model) setFilter(text string) {
+	filter, err := newTextFilter(text, m.filterMode)
+	if err != nil {
+		m.filterErr = err
+		return
+	}
+	m.filter = filter
+	m.filterErr = nil
+}
+
+// viewNumber returns the number of a view picked with a digit key
+func viewNumber(k string) (int, bool) {
+	if len(k) != 1 || k[0] < '0' || k[0] > '9' {
+		return 0, false
+	}
+	return int(k[0] - '0'), true
+}
+
+// pickView shows the nth saved view, counting from 1, or all todos for 0
+func (m *Model) pickView(n int) {
+	if n > len(m.settings.Views) {
+		m.statusMsg = statusMessageStyle(fmt.Sprintf("There is no view %d", n))
+		return
+	}
+	m.inputMode = ModeNormal
+	if n == 0 {
+		m.showAll()
+		return
+	}
+	m.applyView(m.settings.Views[n-1])
+}
+
+// applyView shows a saved view: its query becomes the filter, and its sort
+// order and display options take effect
+func (m *Model) applyView(view ViewSettings) {
+	m.filterMode = matchSubstring
+	m.setFilter(view.Query)
+	m.filterInput.SetValue(view.Query)
+	if mode, ok := sortModes[view.Sort]; ok {
+		m.todoManager.SetSortMode(mode)
+	}
+	if group, ok := parseGrouping(view.Group); ok {
+		m.grouping = group
+	}
+	m.showFuture = view.ShowFuture
+	m.setView(view)
+	m.refreshList()
+	m.statusMsg = statusMessageStyle("View: " + view.Name)
+}
+
+// showAll leaves any saved view, going back to the unfiltered list as the
+// settings file sets it up
+func (m *Model) showAll() {
+	m.setFilter("")
+	m.filterInput.SetValue("")
+	m.todoManager.SetSortMode(sortModes[m.settings.Sort.Default])
+	m.grouping, _ = parseGrouping(m.settings.UI.Group)
+	m.showFuture = m.settings.UI.ShowFuture
+	m.sidebarFilter = ""
+	m.setView(ViewSettings{})
+	m.refreshList()
+	m.statusMsg = statusMessageStyle("Showing all todos")
+}
+
+// setView records the view being shown and names it in the list title
+func (m *Model) setView(view ViewSettings) {
+	m.view = view
+	m.updateTitle()
+}
+
+// updateTitle names the view and the sidebar pick being shown in the list
+// title
+func (m *Model) updateTitle() {
+	m.list.Title = m.settings.UI.Title
+	if m.view.Name != "" {
+		m.list.Title += " · " + m.view.Name
+	}
+	if m.sidebarFilter != "" {
+		m.list.Title += " · " + m.sidebarFilter
+	}
+}
+
+// leaveChangedView forgets the view being shown once its filter has been
+// changed, as it is no longer what the list shows
+func (m *Model) leaveChangedView() {
+	if m.view.Name != "" && (m.filter.text != m.view.Query || m.filter.mode != matchSubstring) {
+		m.setView(ViewSettings{})
+	}
+}
+
+// filterMatches counts the todos the list shows for the filter
+func (m Model) filterMatches() int {
+	now := time.Now()
+	return len(m.filter.apply(m.visibleTodos(m.todoManager.GetTodos(), now), now))
+}
+
+// visibleTodos returns the todos the filter is applied to: those in the
+// sidebar pick, without future todos unless they are revealed
+func (m Model) visibleTodos(all todotxt.List, now time.Time) []todotxt.Todo {
+	entries := sidebarEntries(all)
+	entry := entries[m.sidebarIndex(entries)]
+
+	var todos []todotxt.Todo
+	for _, todo := range all {
+		// Hide todos whose threshold date hasn't arrived yet
+		if !m.showFuture && todo.IsFuture(now) {
+			continue
+		}
+		// Keep to the project or context picked in the sidebar
+		if !entry.match(todo) {
+			continue
+		}
+		todos = append(todos, todo)
+	}
+	return todos
+}
+
 // refreshList updates the list items from the todo manager
 func (m *Model) refreshList() {
-	items := []list.Item{}
-	for _, todo := range m.todoManager.GetTodos() {
-		// Apply filter if active
-		if m.filterText != "" {
-			// Check if todo matches filter (case-insensitive)
-			if strings.Contains(strings.ToLower(todo.Text), strings.ToLower(m.filterText)) ||
-				strings.Contains(strings.ToLower(todo.Raw), strings.ToLower(m.filterText)) {
-				items = append(items, TodoItem{todo: todo})
-			}
-		} else {
-			items = append(items, TodoItem{todo: todo})
-		}
+	all := m.todoManager.GetTodos()
+
+	// Go back to all todos once the last todo of the sidebar pick is gone
+	entries := sidebarEntries(all)
+	if entries[m.sidebarIndex(entries)].name != m.sidebarFilter {
+		m.sidebarFilter = ""
+		m.updateTitle()
+	}
+
+	// Apply filter if active
+	now := time.Now()
+	filtered := m.filter.apply(m.visibleTodos(all, now), now)
+	if m.grouping != groupNone {
+		m.list.SetItems(groupItems(filtered, m.grouping, m.collapsed))
+		return
+	}
+	items := make([]list.Item, len(filtered))
+	for i, item := range filtered {
+		items[i] = item
 	}
 	m.list.SetItems(items)
 }
@@ -570,7 +1303,7 @@ func (m Model) View() string {
 			Render(
 				titleStyle.Render("📋 lazytodo - Help") + "\n\n" +
 					m.help.View(keys) + "\n\n" +
-					helpStyle.Render("Press ? to close help"),
+					helpStyle.Render(fmt.Sprintf("Press %s to close help", helpKey(keys.Help))),
 			)
 	}
 
@@ -581,58 +1314,10 @@ func (m Model) View() string {
 	// Filter mode no longer uses full-screen modal
 	// It now uses the persistent command window
 
-	// Main view
-	var details string
-	if item, ok := m.list.SelectedItem().(TodoItem); ok {
-		todo := item.todo
-		details = detailsStyle.Render(
-			lipgloss.JoinVertical(lipgloss.Left,
-				lipgloss.NewStyle().Bold(true).Render("📝 Todo Details"),
-				"",
-				fmt.Sprintf("ID: %d", todo.ID),
-				fmt.Sprintf("Status: %s", func() string {
-					if todo.Completed {
-						return lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Render("✓ Completed")
-					}
-					return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C")).Render("○ Pending")
-				}()),
-				func() string {
-					if todo.Priority != "" {
-						return fmt.Sprintf("Priority: %s", priorityStyle.Render("("+todo.Priority+")"))
-					}
-					return ""
-				}(),
-				func() string {
-					if todo.CreatedDate != "" {
-						return fmt.Sprintf("Created: %s", todo.CreatedDate)
-					}
-					return ""
-				}(),
-				func() string {
-					if len(todo.Projects) > 0 {
-						return fmt.Sprintf("Projects: %s", projectStyle.Render(strings.Join(todo.Projects, ", ")))
-					}
-					return ""
-				}(),
-				func() string {
-					if len(todo.Contexts) > 0 {
-						return fmt.Sprintf("Contexts: %s", contextStyle.Render(strings.Join(todo.Contexts, ", ")))
-					}
-					return ""
-				}(),
-				"",
-				helpStyle.Render("Raw: "+todo.Raw),
-			),
-		)
-	} else {
-		details = detailsStyle.Render(
-			lipgloss.JoinVertical(lipgloss.Left,
-				lipgloss.NewStyle().Bold(true).Render("📝 Todo Details"),
-				"",
-				helpStyle.Render("Select a todo to view details"),
-			),
-		)
-	}
+	// Main view; the details pane scrolls while it has focus
+	detailLines := strings.Split(m.detailsContent(), "\n")
+	detailLines = detailLines[min(m.detailsOffset, len(detailLines)-1):]
+	details := m.paneStyle(detailsStyle, paneDetails).Render(strings.Join(detailLines, "\n"))
 
 	// Stats with integrated status message
 	todos := m.todoManager.GetTodos()
@@ -664,38 +1349,45 @@ func (m Model) View() string {
 	var commandWindow string
 	// Calculate command window width to match todo list area
 	var commandWidth int
-	if m.height < 20 {
+	if m.compact() {
 		// Small terminal - use most of the width
 		commandWidth = m.width - 12
 	} else {
 		// Normal terminal - match list width
-		commandWidth = m.width*3/5 - 8
+		commandWidth = m.listWidth() - 8
 	}
 	if commandWidth < 30 {
 		commandWidth = 30
 	}
-	commandStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#FF7CCB")).
+	commandStyle := inputStyle.
 		Padding(0, 1).
 		Width(commandWidth)
 
-	if m.inputMode == ModeFilter {
-		// Show filter input with live match count
-		currentFilter := m.filterInput.Value()
-		matchCount := 0
-		if currentFilter != "" {
-			for _, todo := range m.todoManager.GetTodos() {
-				if strings.Contains(strings.ToLower(todo.Text), strings.ToLower(currentFilter)) ||
-					strings.Contains(strings.ToLower(todo.Raw), strings.ToLower(currentFilter)) {
-					matchCount++
-				}
-			}
+	if m.inputMode == ModeConflict {
+		commandWindow = commandStyle.Render(
+			fmt.Sprintf(
+				"⚠ todo.txt changed on disk: o to overwrite with your change • r or %s to reload from disk • %s to quit without saving",
+				helpKey(keys.Escape), helpKey(keys.Quit),
+			),
+		)
+	} else if m.inputMode == ModeViews {
+		// List the views by number, the picker's selection highlighted
+		choices := []string{"0 all"}
+		for i, view := range m.settings.Views {
+			choices = append(choices, fmt.Sprintf("%d %s", i+1, view.Name))
 		}
-
-		filterDisplay := fmt.Sprintf("🔍 Filter: %s", m.filterInput.View())
-		if currentFilter != "" {
-			filterDisplay += fmt.Sprintf(" (%d matches)", matchCount)
+		choices[m.viewCursor] = selectedStyle.Bold(true).Render(choices[m.viewCursor])
+		commandWindow = commandStyle.Render(fmt.Sprintf(
+			"👁 Views: %s (%s to pick, %s to cancel)",
+			strings.Join(choices, " • "), helpKey(keys.Enter), helpKey(keys.Escape),
+		))
+	} else if m.inputMode == ModeFilter {
+		// Show filter input with live match count, or why it doesn't parse
+		filterDisplay := fmt.Sprintf("🔍 Filter (%s, %s to switch): %s", m.filterMode, helpKey(keys.MatchMode), m.filterInput.View())
+		if m.filterErr != nil {
+			filterDisplay += " ⚠ " + m.filterErr.Error()
+		} else if m.filter.text != "" {
+			filterDisplay += fmt.Sprintf(" (%d matches)", m.filterMatches())
 		}
 		commandWindow = commandStyle.Render(filterDisplay)
 	} else if m.inputMode == ModeAdd {
@@ -708,60 +1400,60 @@ func (m Model) View() string {
 		commandWindow = commandStyle.Render(
 			fmt.Sprintf("✏️ Edit Todo: %s", m.textInput.View()),
 		)
-	} else if m.filterText != "" {
+	} else if m.filter.text != "" {
 		// Show active filter status with match count
-		matchCount := 0
-		for _, todo := range m.todoManager.GetTodos() {
-			if strings.Contains(strings.ToLower(todo.Text), strings.ToLower(m.filterText)) ||
-				strings.Contains(strings.ToLower(todo.Raw), strings.ToLower(m.filterText)) {
-				matchCount++
-			}
-		}
 		commandWindow = commandStyle.Render(
-			fmt.Sprintf("🔍 Active filter: '%s' (%d matches, press / to edit)", m.filterText, matchCount),
+			fmt.Sprintf("🔍 Active %s filter: '%s' (%d matches, press %s to edit)", m.filter.mode, m.filter.text, m.filterMatches(), helpKey(keys.Filter)),
 		)
 	} else {
 		// Show default command prompt
-		commandWindow = commandStyle.Render(
-			"Command: a to add • e to edit • / to filter • ? for help",
-		)
+		commandWindow = commandStyle.Render(fmt.Sprintf(
+			"Command: %s to add • %s to edit • %s to filter • %s for help",
+			helpKey(keys.Add), helpKey(keys.Edit), helpKey(keys.Filter), helpKey(keys.Help),
+		))
+	}
+
+	// Completions for the word being typed take the spacing line
+	spacing := ""
+	if m.inputMode == ModeAdd || m.inputMode == ModeEdit {
+		spacing = " " + m.completion.View()
 	}
 
 	// Create todo list content with command window at top
 	todoListContent := lipgloss.JoinVertical(
 		lipgloss.Left,
 		commandWindow,
-		"", // Spacing
+		spacing,
 		m.list.View(),
 	)
 
 	// Layout with proper spacing - adjust for terminal size
 	var mainContent string
-	if m.height < 20 {
+	if m.compact() {
 		// Small terminal - show only todo list with command window
 		mainContent = lipgloss.NewStyle().
 			Margin(1, 0).
 			Render(todoListContent) // todoListContent already includes command window
 	} else {
-		// Normal terminal - show both todo list and details
+		// Normal terminal - show the sidebar, todo list and details
+		todoList := m.paneStyle(todoListStyle, paneList).Render(todoListContent)
+		panels := []string{todoList, " ", details} // Add space between panels
+		if m.sidebarWidth() > 0 {
+			panels = append([]string{m.sidebarView(lipgloss.Height(todoList)), " "}, panels...)
+		}
 		mainContent = lipgloss.NewStyle().
 			Margin(1, 0).
-			Render(
-				lipgloss.JoinHorizontal(
-					lipgloss.Top,
-					todoListStyle.Render(todoListContent),
-					" ", // Add space between panels
-					details,
-				),
-			)
+			Render(lipgloss.JoinHorizontal(lipgloss.Top, panels...))
 	}
 
 	// Show appropriate help text based on mode
 	var helpText string
 	if m.inputMode == ModeAdd || m.inputMode == ModeEdit || m.inputMode == ModeFilter {
-		helpText = "Enter: save • ESC: cancel • ? for help • q to quit"
+		helpText = fmt.Sprintf("%s: save • %s: cancel", helpKey(keys.Enter), helpKey(keys.Escape))
+	} else if m.inputMode == ModeConflict {
+		helpText = fmt.Sprintf("o: keep your change • r/%s: take the version on disk • %s: quit", helpKey(keys.Escape), helpKey(keys.Quit))
 	} else {
-		helpText = "Press ? for help • q to quit"
+		helpText = fmt.Sprintf("Press %s for help • %s to quit", helpKey(keys.Help), helpKey(keys.Quit))
 	}
 
 	return appStyle.Render(
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jakeasaurus/lazytodo/todotxt"
+	"github.com/sahilm/fuzzy"
+)
+
+// maxSuggestions is how many completions the suggestion line shows
+const maxSuggestions = 8
+
+// completion offers the projects, contexts and tag keys already in use for
+// the word at the cursor of the add/edit input. Tab cycles through them.
+type completion struct {
+	// words are every +project, @context and key: in the todo and done
+	// lists
+	words []string
+	// candidates are the words matching what was typed, best first.
+	// index is the candidate Tab last put in the input, -1 before that.
+	candidates []string
+	index      int
+	// start is the byte offset of the word being completed, and typed is
+	// the word as it was typed
+	start int
+	typed string
+}
+
+// newCompletion offers the projects, contexts and tag keys of the todo
+// and done lists. A done list that can't be read only leaves its words out.
+func newCompletion(tm *todotxt.TodoManager) completion {
+	lists := []todotxt.List{tm.GetTodos()}
+	if done, err := tm.GetDoneTodos(); err == nil {
+		lists = append(lists, done)
+	}
+
+	seen := map[string]bool{}
+	var words []string
+	add := func(word string) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	for _, list := range lists {
+		for _, project := range list.Projects() {
+			add("+" + project)
+		}
+		for _, context := range list.Contexts() {
+			add("@" + context)
+		}
+		for _, key := range list.TagKeys() {
+			add(key + ":")
+		}
+	}
+	sort.Strings(words)
+	return completion{words: words, index: -1}
+}
+
+// update finds the candidates for the word ending at the cursor, which is
+// at rune pos of value
+func (c *completion) update(value string, pos int) {
+	c.candidates, c.index = nil, -1
+	pos = len(string([]rune(value)[:pos]))
+	if pos < len(value) && value[pos] != ' ' {
+		// The cursor is inside a word
+		return
+	}
+	c.start = strings.LastIndexByte(value[:pos], ' ') + 1
+	c.typed = value[c.start:pos]
+	c.candidates = c.match(c.typed)
+}
+
+// match returns the words typed could be completed to. Words starting
+// with typed come first, ignoring case. Projects and contexts then also
+// match fuzzily, so +wrk finds +work; tag keys only match by prefix so
+// ordinary words don't offer them.
+func (c *completion) match(typed string) []string {
+	if typed == "" || strings.Contains(typed[1:], ":") {
+		return nil
+	}
+	fuzzyToo := typed[0] == '+' || typed[0] == '@'
+
+	var prefixed, others []string
+	for _, word := range c.words {
+		switch {
+		case word == typed:
+			continue
+		case strings.HasPrefix(strings.ToLower(word), strings.ToLower(typed)):
+			prefixed = append(prefixed, word)
+		case fuzzyToo && word[0] == typed[0]:
+			others = append(others, word)
+		}
+	}
+	if fuzzyToo && len(typed) > 1 {
+		for _, match := range fuzzy.Find(typed[1:], trimSigils(others)) {
+			prefixed = append(prefixed, others[match.Index])
+		}
+	}
+	return prefixed
+}
+
+// trimSigils returns words without their leading + or @
+func trimSigils(words []string) []string {
+	trimmed := make([]string, len(words))
+	for i, word := range words {
+		trimmed[i] = word[1:]
+	}
+	return trimmed
+}
+
+// cycle puts the next candidate, or the previous one for a negative step,
+// in place of the word being completed. It returns the new input value and
+// cursor position, or false when there is nothing to complete.
+func (c *completion) cycle(value string, step int) (string, int, bool) {
+	if len(c.candidates) == 0 {
+		return value, 0, false
+	}
+	current := c.typed
+	if c.index >= 0 {
+		current = c.candidates[c.index]
+	}
+	switch {
+	case c.index < 0 && step < 0:
+		c.index = len(c.candidates) - 1
+	case c.index < 0:
+		c.index = 0
+	default:
+		c.index = (c.index + step + len(c.candidates)) % len(c.candidates)
+	}
+	next := c.candidates[c.index]
+	end := c.start + len(current)
+	return value[:c.start] + next + value[end:], utf8.RuneCountInString(value[:c.start] + next), true
+}
+
+// View renders the suggestion line, the candidate in the input highlighted
+func (c completion) View() string {
+	if len(c.candidates) == 0 {
+		return ""
+	}
+	// Scroll so the candidate in the input stays in sight
+	first := max(c.index-maxSuggestions+1, 0)
+	shown := make([]string, 0, maxSuggestions+2)
+	if first > 0 {
+		shown = append(shown, helpStyle.Render("…"))
+	}
+	for i := first; i < len(c.candidates); i++ {
+		if i == first+maxSuggestions {
+			shown = append(shown, helpStyle.Render("…"))
+			break
+		}
+		word := c.candidates[i]
+		if i == c.index {
+			word = selectedStyle.Bold(true).Render(word)
+		}
+		shown = append(shown, word)
+	}
+	return helpStyle.Render(helpKey(keys.Complete)+": ") + strings.Join(shown, "  ")
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jakeasaurus/lazytodo/todotxt"
+	"github.com/sahilm/fuzzy"
+)
+
+// matchMode is how the filter text is matched against todos
+type matchMode int
+
+const (
+	// matchSubstring reads the filter as a query, whose words match
+	// anywhere in a todo
+	matchSubstring matchMode = iota
+	// matchFuzzy matches the letters of the filter in order, best
+	// matches first
+	matchFuzzy
+	// matchRegex matches a regular expression, ignoring case
+	matchRegex
+)
+
+// matchModeNames are the names of the match modes, in toggle order
+var matchModeNames = []string{"substring", "fuzzy", "regex"}
+
+func (m matchMode) String() string {
+	return matchModeNames[m]
+}
+
+// next returns the mode the match mode toggle switches to
+func (m matchMode) next() matchMode {
+	return (m + 1) % matchMode(len(matchModeNames))
+}
+
+// parseMatchMode returns the match mode with the given name
+func parseMatchMode(name string) (matchMode, bool) {
+	for i, modeName := range matchModeNames {
+		if name == modeName {
+			return matchMode(i), true
+		}
+	}
+	return matchSubstring, false
+}
+
+// textFilter is filter text that makes sense in its match mode
+type textFilter struct {
+	text  string
+	mode  matchMode
+	query *todotxt.Query
+	regex *regexp.Regexp
+}
+
+// newTextFilter prepares text for matching in mode. A query or regex that
+// doesn't parse is an error.
+func newTextFilter(text string, mode matchMode) (textFilter, error) {
+	f := textFilter{text: text, mode: mode}
+	switch mode {
+	case matchSubstring:
+		query, err := todotxt.ParseQuery(text)
+		if err != nil {
+			return f, err
+		}
+		f.query = query
+	case matchRegex:
+		// Compile as written first, so errors quote the user's regex
+		if _, err := regexp.Compile(text); err != nil {
+			return f, fmt.Errorf("invalid regex: %w", err)
+		}
+		f.regex = regexp.MustCompile("(?i)" + text)
+	}
+	return f, nil
+}
+
+// apply returns the todos that match as list items, with the runes that
+// matched marked for highlighting. Fuzzy matches come best first; the
+// other modes keep the order of todos.
+func (f textFilter) apply(todos []todotxt.Todo, now time.Time) []TodoItem {
+	items := make([]TodoItem, 0, len(todos))
+	switch {
+	case f.mode == matchFuzzy && f.text != "":
+		for _, match := range fuzzy.FindFrom(f.text, todoTexts(todos)) {
+			items = append(items, TodoItem{todo: todos[match.Index], matches: match.MatchedIndexes})
+		}
+	case f.mode == matchRegex && f.text != "":
+		for _, todo := range todos {
+			if spans := f.regex.FindAllStringIndex(todo.Text, -1); spans != nil {
+				items = append(items, TodoItem{todo: todo, matches: spanIndexes(todo.Text, spans)})
+			}
+		}
+	default:
+		for _, todo := range todos {
+			if f.query.Match(todo, now) {
+				items = append(items, TodoItem{todo: todo})
+			}
+		}
+	}
+	return items
+}
+
+// todoTexts lets fuzzy search the text of todos without copying it
+type todoTexts []todotxt.Todo
+
+func (t todoTexts) String(i int) string { return t[i].Text }
+func (t todoTexts) Len() int            { return len(t) }
+
+// spanIndexes returns the byte index of every rune inside the spans
+func spanIndexes(text string, spans [][]int) []int {
+	var indexes []int
+	for _, span := range spans {
+		for i := span[0]; i < span[1]; {
+			indexes = append(indexes, i)
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+		}
+	}
+	return indexes
+}
+
+// highlightMatches renders the runes of text starting at the given byte
+// indexes with matchStyle, a run of them at a time
+func highlightMatches(text string, indexes []int) string {
+	if len(indexes) == 0 {
+		return text
+	}
+	matched := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		matched[i] = true
+	}
+
+	var b strings.Builder
+	start := -1
+	for i, r := range text {
+		switch {
+		case matched[i] && start < 0:
+			start = i
+		case !matched[i] && start >= 0:
+			b.WriteString(matchStyle.Render(text[start:i]))
+			start = -1
+		}
+		if start < 0 {
+			b.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		b.WriteString(matchStyle.Render(text[start:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jakeasaurus/lazytodo/todotxt"
+)
+
+// grouping is what the list gathers todos under headers by
+type grouping int
+
+const (
+	groupNone grouping = iota
+	groupProject
+	groupContext
+	groupPriority
+)
+
+// groupingNames are the names of the groupings, in cycle order
+var groupingNames = []string{"none", "project", "context", "priority"}
+
+func (g grouping) String() string {
+	return groupingNames[g]
+}
+
+// next returns the grouping the cycle key switches to
+func (g grouping) next() grouping {
+	return (g + 1) % grouping(len(groupingNames))
+}
+
+// parseGrouping returns the grouping with the given name
+func parseGrouping(name string) (grouping, bool) {
+	for i, groupingName := range groupingNames {
+		if name == groupingName {
+			return grouping(i), true
+		}
+	}
+	return groupNone, false
+}
+
+// groups returns the groups a todo is listed under. A todo with several
+// projects or contexts is listed under each of them.
+func (g grouping) groups(todo todotxt.Todo) []string {
+	var groups []string
+	switch g {
+	case groupProject:
+		for _, project := range todo.Projects {
+			groups = append(groups, "+"+project)
+		}
+	case groupContext:
+		for _, context := range todo.Contexts {
+			groups = append(groups, "@"+context)
+		}
+	case groupPriority:
+		if todo.Priority != "" {
+			groups = append(groups, "("+todo.Priority+")")
+		}
+	}
+	if len(groups) == 0 {
+		groups = append(groups, "(no "+g.String()+")")
+	}
+	return groups
+}
+
+// GroupHeader is a list item heading the todos of a group
+type GroupHeader struct {
+	name      string
+	open      int
+	done      int
+	collapsed bool
+}
+
+func (h GroupHeader) FilterValue() string { return h.name }
+
+func (h GroupHeader) Title() string {
+	arrow := "▾"
+	if h.collapsed {
+		arrow = "▸"
+	}
+	style := lipgloss.NewStyle()
+	switch h.name[0] {
+	case '+':
+		style = projectStyle
+	case '@':
+		style = contextStyle
+	case '(':
+		if len(h.name) == 3 {
+			style = priorityStyle
+		}
+	}
+	return fmt.Sprintf("%s %s (%d)", arrow, style.Bold(true).Render(h.name), h.open+h.done)
+}
+
+func (h GroupHeader) Description() string {
+	desc := fmt.Sprintf("%d open • %d done", h.open, h.done)
+	if h.collapsed {
+		desc += " • collapsed, " + helpKey(keys.Collapse) + " to expand"
+	}
+	return helpStyle.Render(desc)
+}
+
+// groupItems lists items under a header for each of their groups. Groups
+// come in name order, with todos in none of them last; within a group
+// the items keep their order. A collapsed group shows only its header.
+func groupItems(items []TodoItem, g grouping, collapsed map[string]bool) []list.Item {
+	members := map[string][]TodoItem{}
+	for _, item := range items {
+		for _, group := range g.groups(item.todo) {
+			members[group] = append(members[group], item)
+		}
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	none := "(no " + g.String() + ")"
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == none) != (names[j] == none) {
+			return names[j] == none
+		}
+		return names[i] < names[j]
+	})
+
+	var grouped []list.Item
+	for _, name := range names {
+		header := GroupHeader{name: name, collapsed: collapsed[name]}
+		for _, item := range members[name] {
+			if item.todo.Completed {
+				header.done++
+			} else {
+				header.open++
+			}
+		}
+		grouped = append(grouped, header)
+		if header.collapsed {
+			continue
+		}
+		for _, item := range members[name] {
+			grouped = append(grouped, item)
+		}
+	}
+	return grouped
+}
+
+// selectedGroup returns the group the selected item is listed under: the
+// header itself, or the nearest header above a todo
+func (m Model) selectedGroup() (string, bool) {
+	items := m.list.Items()
+	for i := m.list.Index(); i >= 0 && i < len(items); i-- {
+		if header, ok := items[i].(GroupHeader); ok {
+			return header.name, true
+		}
+	}
+	return "", false
+}
+
+// selectGroup moves the selection to the header of a group
+func (m *Model) selectGroup(name string) {
+	for i, item := range m.list.Items() {
+		if header, ok := item.(GroupHeader); ok && header.name == name {
+			m.list.Select(i)
+			return
+		}
+	}
+}
@@ -3,25 +3,97 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jakeasaurus/lazytodo/todotxt"
 )
 
 func main() {
-	if len(os.Args) > 1 {
-		switch os.Args[1] {
+	args := os.Args[1:]
+
+	// Like todo.sh, -d picks the config file. --view opens the TUI on a
+	// saved view.
+	configPath, viewName := "", ""
+	for len(args) > 0 && (args[0] == "-d" || args[0] == "--view") {
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Error: %s needs a value\n", args[0])
+			os.Exit(1)
+		}
+		if args[0] == "-d" {
+			configPath = args[1]
+		} else {
+			viewName = args[1]
+		}
+		args = args[2:]
+	}
+
+	config, err := todotxt.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// There is no TUI to show when the output is piped, so do what
+	// todo.sh would do without arguments
+	if len(args) == 0 && viewName == "" && config.DefaultAction != "" && !isTerminal(os.Stdout) {
+		args = strings.Fields(config.DefaultAction)
+		if len(args) == 0 || !isCommand(args[0]) {
+			fmt.Fprintf(os.Stderr, "Error: TODOTXT_DEFAULT_ACTION %q isn't supported by lazytodo\n", config.DefaultAction)
+			os.Exit(1)
+		}
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
 		case "--version", "-v":
 			fmt.Println("lazytodo v0.2.0 (Charm Edition)")
 			return
 		case "--help", "-h":
+			// Show the keys as remapped in the settings file
+			if settings, _, err := loadSettings(settingsPath()); err == nil {
+				settings.apply()
+			}
 			printHelp()
 			return
+		case "ls", "list":
+			runList(config, args[1:])
+			return
+		case "archive":
+			runArchive(config)
+			return
+		case "restore":
+			runRestore(config, args[1:])
+			return
+		case "config":
+			runConfig(args[1:])
+			return
 		}
 	}
 
+	settings, _, err := loadSettings(settingsPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %s:\n%v\n", settingsPath(), err)
+		fmt.Fprintln(os.Stderr, "Run 'lazytodo config check' for details")
+		os.Exit(1)
+	}
+
+	model := initialModel(openTodoManager(config), settings)
+	if viewName != "" {
+		view, ok := settings.view(viewName)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: no view named %q in %s (available: %s)\n",
+				viewName, settingsPath(), strings.Join(settings.viewNames(), ", "))
+			os.Exit(1)
+		}
+		model.applyView(view)
+	}
+
 	// Start Bubble Tea app
 	p := tea.NewProgram(
-		initialModel(),
+		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
@@ -36,41 +108,148 @@ func main() {
 	fmt.Fprint(os.Stderr, "\033[?25h")
 }
 
+// isCommand reports whether name is a command lazytodo runs without the TUI
+func isCommand(name string) bool {
+	switch name {
+	case "ls", "list", "archive", "restore":
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether file is a terminal rather than a pipe or file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// openTodoManager opens the configured todo file, exiting on failure
+func openTodoManager(config todotxt.Config) *todotxt.TodoManager {
+	tm, err := todotxt.NewTodoManager(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return tm
+}
+
+// runList prints the todos like `todo.sh ls`. Every term must appear in a
+// todo for it to be listed, and a term starting with - must not.
+func runList(config todotxt.Config, terms []string) {
+	tm := openTodoManager(config)
+	todos := tm.GetTodos()
+
+	width := 1
+	for _, todo := range todos {
+		width = max(width, len(strconv.Itoa(todo.ID)))
+	}
+
+	shown := 0
+	for _, todo := range todos {
+		if !matchesTerms(todo.Raw, terms) {
+			continue
+		}
+		fmt.Printf("%0*d %s\n", width, todo.ID, todo.Raw)
+		shown++
+	}
+	fmt.Println("--")
+	fmt.Printf("TODO: %d of %d tasks shown\n", shown, len(todos))
+}
+
+// matchesTerms reports whether line contains every term, ignoring case.
+// Terms starting with - must be absent instead.
+func matchesTerms(line string, terms []string) bool {
+	line = strings.ToLower(line)
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		if exclude, ok := strings.CutPrefix(term, "-"); ok && exclude != "" {
+			if strings.Contains(line, exclude) {
+				return false
+			}
+		} else if !strings.Contains(line, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// runArchive moves completed todos to the done file without starting the TUI
+func runArchive(config todotxt.Config) {
+	tm := openTodoManager(config)
+	count, err := tm.Archive()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Archived %d completed todos to %s\n", count, tm.DoneFile())
+}
+
+// runRestore lists the todo file backups, or rolls back to one of them
+func runRestore(config todotxt.Config, args []string) {
+	tm := openTodoManager(config)
+
+	if len(args) == 0 {
+		backups, err := tm.Backups()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(backups) == 0 {
+			fmt.Printf("No backups of %s yet\n", tm.FilePath())
+			return
+		}
+		fmt.Printf("Backups of %s (newest first):\n", tm.FilePath())
+		for _, backup := range backups {
+			fmt.Printf("  %d  %s  %d lines\n", backup.Index, backup.ModTime.Format("2006-01-02 15:04:05"), backup.Lines)
+		}
+		fmt.Println("")
+		fmt.Println("Run 'lazytodo restore <n>' to roll back to backup n")
+		return
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid backup number %q\n", args[0])
+		os.Exit(1)
+	}
+	if err := tm.Restore(n); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored %s from backup %d\n", tm.FilePath(), n)
+}
+
 func printHelp() {
 	fmt.Println("lazytodo - A TUI wrapper for todo.txt (Charm Edition)")
 	fmt.Println("")
 	fmt.Println("Usage:")
 	fmt.Println("  lazytodo                 Start the TUI")
+	fmt.Println("  lazytodo ls [term...]    List todos containing every term, like todo.sh ls")
+	fmt.Println("  lazytodo archive         Move completed todos to done.txt")
+	fmt.Println("  lazytodo restore         List backups of todo.txt")
+	fmt.Println("  lazytodo restore <n>     Roll todo.txt back to backup n")
+	fmt.Println("  lazytodo config check    Validate the settings file")
 	fmt.Println("  lazytodo --version       Show version")
 	fmt.Println("  lazytodo --help          Show this help")
 	fmt.Println("")
-	fmt.Println("Key bindings (once in TUI):")
-	fmt.Println("Navigation:")
-	fmt.Println("  j/↓        Move down")
-	fmt.Println("  k/↑        Move up")
-	fmt.Println("  g/Home     Go to top")
-	fmt.Println("  G/End      Go to bottom")
-	fmt.Println("")
-	fmt.Println("Todo actions:")
-	fmt.Println("  a          Add new todo")
-	fmt.Println("  e          Edit todo")
-	fmt.Println("  d          Delete todo")
-	fmt.Println("  x/Space    Toggle todo completion")
-	fmt.Println("")
-	fmt.Println("Priority:")
-	fmt.Println("  1          Set priority A (highest)")
-	fmt.Println("  2          Set priority B")
-	fmt.Println("  3          Set priority C")
+	fmt.Println("Options:")
+	fmt.Println("  -d <config>              Use a todo.sh config file other than the default")
+	fmt.Println("  --view <name>            Open the TUI on a saved view from the settings file")
 	fmt.Println("")
-	fmt.Println("Other:")
-	fmt.Println("  r          Refresh from file")
-	fmt.Println("  /          Filter/search todos")
-	fmt.Println("  ?          Show/hide help")
-	fmt.Println("  q/Ctrl+C   Quit")
-	fmt.Println("")
-	fmt.Println("Input mode keys:")
-	fmt.Println("  Enter      Submit input")
-	fmt.Println("  Esc        Cancel input")
+	fmt.Println("In the TUI, alt+1 to alt+9 switch to saved views 1 to 9 and alt+0 shows all todos.")
 	fmt.Println("")
+	fmt.Println("Key bindings (once in TUI):")
+	titles := []string{"Navigation", "Todo actions", "Priority", "Other"}
+	groups := keys.FullHelp()
+	titles = append(titles, "Input mode keys")
+	groups = append(groups, []key.Binding{keys.Enter, keys.Escape, keys.MatchMode, keys.Complete, keys.CompletePrev})
+	for i, group := range groups {
+		fmt.Printf("%s:\n", titles[i])
+		for _, binding := range group {
+			desc := binding.Help().Desc
+			fmt.Printf("  %-10s %s\n", binding.Help().Key, strings.ToUpper(desc[:1])+desc[1:])
+		}
+		fmt.Println("")
+	}
 	fmt.Println("🎭 Powered by Charm - https://charm.sh")
 }
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jakeasaurus/lazytodo/todotxt"
+)
+
+// Settings holds lazytodo's own preferences, kept apart from the todo.sh
+// config in ~/.config/lazytodo/config.toml
+type Settings struct {
+	UI     UISettings     `toml:"ui"`
+	Layout LayoutSettings `toml:"layout"`
+	Sort   SortSettings   `toml:"sort"`
+	// Colors overrides individual colors of the theme
+	Colors Theme `toml:"colors"`
+	// Keys remaps key map actions, keyed by the names in keyMap.actions
+	Keys map[string]keyList `toml:"keys"`
+	// Views are saved views, numbered in the order they are written
+	Views []ViewSettings `toml:"views"`
+}
+
+// ViewSettings is a saved view: a filter query along with the sort order
+// and display options to show it with
+type ViewSettings struct {
+	Name  string `toml:"name"`
+	Query string `toml:"query"`
+	// Sort is one of the [sort] default modes; empty keeps the current one
+	Sort string `toml:"sort"`
+	// Group is a grouping as in [ui] group; empty keeps the current one
+	Group      string `toml:"group"`
+	ShowFuture bool   `toml:"show_future"`
+}
+
+// keyList is one or more keys, written as a string or an array of strings
+type keyList []string
+
+// UnmarshalTOML accepts both add = "n" and toggle = ["x", "space"]
+func (l *keyList) UnmarshalTOML(data any) error {
+	switch value := data.(type) {
+	case string:
+		*l = keyList{value}
+	case []any:
+		keys := make(keyList, 0, len(value))
+		for _, item := range value {
+			k, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a key name, got %v", item)
+			}
+			keys = append(keys, k)
+		}
+		*l = keys
+	default:
+		return fmt.Errorf("expected a key or a list of keys, got %v", data)
+	}
+	return nil
+}
+
+// UISettings controls how todos are shown and entered
+type UISettings struct {
+	// Theme names a bundled theme or a file in the themes directory
+	Theme string `toml:"theme"`
+	// Background picks the light or dark variant of the theme's colors;
+	// auto asks the terminal
+	Background  string `toml:"background"`
+	Title       string `toml:"title"`
+	CharLimit   int    `toml:"char_limit"`
+	ShowFuture  bool   `toml:"show_future"`
+	DueSoonDays int    `toml:"due_soon_days"`
+	// FilterMode is how the filter matches at startup: substring, fuzzy
+	// or regex
+	FilterMode string `toml:"filter_mode"`
+	// Group gathers todos under headers at startup: none, project,
+	// context or priority
+	Group string `toml:"group"`
+}
+
+// LayoutSettings controls how the screen is divided
+type LayoutSettings struct {
+	// ListWidth is the share of the screen width given to the todo list;
+	// the details panel gets the rest
+	ListWidth float64 `toml:"list_width"`
+	// CompactHeight is the terminal height below which the details panel
+	// is hidden
+	CompactHeight int `toml:"compact_height"`
+	MaxInputWidth int `toml:"max_input_width"`
+	// SidebarWidth is the width of the project and context sidebar; 0
+	// hides it
+	SidebarWidth int `toml:"sidebar_width"`
+}
+
+// SortSettings controls the order todos are shown in at startup
+type SortSettings struct {
+	Default string `toml:"default"`
+}
+
+// sortModes maps the names accepted by [sort] default to sort modes
+var sortModes = map[string]todotxt.SortMode{
+	"priority": todotxt.SortPriority,
+	"due":      todotxt.SortDue,
+	"file":     todotxt.SortFile,
+}
+
+// defaultSettings returns the settings used when the settings file leaves
+// something out
+func defaultSettings() Settings {
+	return Settings{
+		UI: UISettings{
+			Theme:       "default",
+			Background:  "auto",
+			Title:       "📋 Todo List",
+			CharLimit:   200,
+			DueSoonDays: 3,
+			FilterMode:  "substring",
+			Group:       "none",
+		},
+		Layout: LayoutSettings{
+			ListWidth:     0.6,
+			CompactHeight: 20,
+			MaxInputWidth: 80,
+			SidebarWidth:  26,
+		},
+		Sort: SortSettings{Default: "priority"},
+	}
+}
+
+// settingsPath returns where the settings file lives, following the XDG
+// base directory spec on every platform
+func settingsPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "lazytodo", "config.toml")
+}
+
+// loadSettings reads the settings file at path on top of the defaults. A
+// missing file is fine; a malformed one or an invalid value is an error.
+// Unknown keys are ignored here and reported by `lazytodo config check`.
+func loadSettings(path string) (Settings, toml.MetaData, error) {
+	settings := defaultSettings()
+	meta, err := toml.DecodeFile(path, &settings)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultSettings(), meta, nil
+	}
+	if err != nil {
+		return settings, meta, err
+	}
+	return settings, meta, settings.validate()
+}
+
+// hexColorRegex matches the #RGB and #RRGGBB colors lipgloss understands
+var hexColorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether value is a hex color or an ANSI color number
+func validColor(value string) bool {
+	if hexColorRegex.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// validate checks the values of the settings, reporting every problem
+func (s Settings) validate() error {
+	var errs []error
+	if s.UI.CharLimit < 1 {
+		errs = append(errs, fmt.Errorf("ui.char_limit must be at least 1, got %d", s.UI.CharLimit))
+	}
+	if s.UI.DueSoonDays < 0 {
+		errs = append(errs, fmt.Errorf("ui.due_soon_days can't be negative, got %d", s.UI.DueSoonDays))
+	}
+	if _, ok := parseMatchMode(s.UI.FilterMode); !ok {
+		errs = append(errs, fmt.Errorf("ui.filter_mode must be one of substring, fuzzy or regex, got %q", s.UI.FilterMode))
+	}
+	if _, ok := parseGrouping(s.UI.Group); !ok {
+		errs = append(errs, fmt.Errorf("ui.group must be one of none, project, context or priority, got %q", s.UI.Group))
+	}
+	if s.Layout.ListWidth < 0.2 || s.Layout.ListWidth > 0.8 {
+		errs = append(errs, fmt.Errorf("layout.list_width must be between 0.2 and 0.8, got %g", s.Layout.ListWidth))
+	}
+	if s.Layout.CompactHeight < 0 {
+		errs = append(errs, fmt.Errorf("layout.compact_height can't be negative, got %d", s.Layout.CompactHeight))
+	}
+	if s.Layout.MaxInputWidth < 30 {
+		errs = append(errs, fmt.Errorf("layout.max_input_width must be at least 30, got %d", s.Layout.MaxInputWidth))
+	}
+	if s.Layout.SidebarWidth != 0 && (s.Layout.SidebarWidth < 16 || s.Layout.SidebarWidth > 60) {
+		errs = append(errs, fmt.Errorf("layout.sidebar_width must be 0 or between 16 and 60, got %d", s.Layout.SidebarWidth))
+	}
+	if _, ok := sortModes[s.Sort.Default]; !ok {
+		errs = append(errs, fmt.Errorf("sort.default must be one of priority, due or file, got %q", s.Sort.Default))
+	}
+	names := map[string]bool{}
+	for i, view := range s.Views {
+		switch {
+		case view.Name == "":
+			errs = append(errs, fmt.Errorf("views[%d] needs a name", i))
+		case names[view.Name]:
+			errs = append(errs, fmt.Errorf("views: %q is defined twice", view.Name))
+		}
+		names[view.Name] = true
+		if _, err := todotxt.ParseQuery(view.Query); err != nil {
+			errs = append(errs, fmt.Errorf("views.%s.query: %w", view.Name, err))
+		}
+		if _, ok := sortModes[view.Sort]; view.Sort != "" && !ok {
+			errs = append(errs, fmt.Errorf("views.%s.sort must be one of priority, due or file, got %q", view.Name, view.Sort))
+		}
+		if _, ok := parseGrouping(view.Group); view.Group != "" && !ok {
+			errs = append(errs, fmt.Errorf("views.%s.group must be one of none, project, context or priority, got %q", view.Name, view.Group))
+		}
+	}
+	if _, err := remapKeys(keys, s.Keys); err != nil {
+		errs = append(errs, err)
+	}
+	switch s.UI.Background {
+	case "auto", "light", "dark":
+	default:
+		errs = append(errs, fmt.Errorf("ui.background must be one of auto, light or dark, got %q", s.UI.Background))
+	}
+	if _, err := loadTheme(s.UI.Theme); err != nil {
+		errs = append(errs, err)
+	}
+	for _, name := range sortedKeys(s.Colors) {
+		if _, ok := colorRoles[name]; !ok {
+			continue
+		}
+		if !s.Colors[name].valid() {
+			errs = append(errs, fmt.Errorf("colors.%s must be a hex color like #FF5F87 or an ANSI color number", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unknownKeys returns the keys in the settings file lazytodo doesn't know,
+// which are usually typos
+func (s Settings) unknownKeys(meta toml.MetaData) []string {
+	var unknown []string
+	for _, key := range meta.Undecoded() {
+		unknown = append(unknown, key.String())
+	}
+	for _, name := range sortedKeys(s.Colors) {
+		if _, ok := colorRoles[name]; !ok {
+			unknown = append(unknown, "colors."+name)
+		}
+	}
+	actions := map[string]bool{}
+	for _, action := range keys.actions() {
+		actions[action.name] = true
+	}
+	for _, name := range sortedKeys(s.Keys) {
+		if !actions[name] {
+			unknown = append(unknown, "keys."+name)
+		}
+	}
+	return unknown
+}
+
+// remapKeys returns base with the actions in remap bound to new keys. A key
+// bound to two actions that apply at the same time is an error.
+func remapKeys(base keyMap, remap map[string]keyList) (keyMap, error) {
+	var errs []error
+	k := base
+	for _, action := range k.actions() {
+		list, ok := remap[action.name]
+		if !ok {
+			continue
+		}
+		if len(list) == 0 {
+			errs = append(errs, fmt.Errorf("keys.%s needs at least one key", action.name))
+			continue
+		}
+		bound := make([]string, len(list))
+		for i, name := range list {
+			// bubbletea names the space bar " "
+			if name == "space" {
+				name = " "
+			}
+			bound[i] = name
+		}
+		action.binding.SetKeys(bound...)
+		action.binding.SetHelp(keyLabel(bound), action.binding.Help().Desc)
+	}
+
+	owners := map[string]string{}
+	for _, action := range k.actions() {
+		group := "normal"
+		if inputActions[action.name] {
+			group = "input"
+		}
+		for _, name := range action.binding.Keys() {
+			if owner, taken := owners[group+"\x00"+name]; taken {
+				errs = append(errs, fmt.Errorf("keys: %s is bound to both %s and %s", keyLabel([]string{name}), owner, action.name))
+				continue
+			}
+			owners[group+"\x00"+name] = action.name
+		}
+	}
+	return k, errors.Join(errs...)
+}
+
+// view returns the saved view with the given name
+func (s Settings) view(name string) (ViewSettings, bool) {
+	for _, view := range s.Views {
+		if view.Name == name {
+			return view, true
+		}
+	}
+	return ViewSettings{}, false
+}
+
+// viewNames returns the names of the saved views in order
+func (s Settings) viewNames() []string {
+	names := make([]string, len(s.Views))
+	for i, view := range s.Views {
+		names[i] = view.Name
+	}
+	return names
+}
+
+// apply puts the settings that live outside the model into effect
+func (s Settings) apply() {
+	dueSoonDays = s.UI.DueSoonDays
+	keys, _ = remapKeys(keys, s.Keys)
+
+	switch s.UI.Background {
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	}
+	theme, err := loadTheme(s.UI.Theme)
+	if err != nil {
+		theme = bundledThemes["default"]
+	}
+	applyTheme(theme, s.Colors, s.UI.Theme == monochromeTheme || noColor())
+}
+
+// sortedKeys returns the keys of a map in order, for stable reports
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runConfig handles `lazytodo config`
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Println("Usage: lazytodo config check [file]")
+		fmt.Println("")
+		fmt.Printf("Settings file: %s\n", settingsPath())
+		return
+	}
+
+	path := settingsPath()
+	if len(args) > 1 {
+		path = args[1]
+	}
+	if _, err := os.Stat(path); err != nil {
+		fmt.Printf("%s: no settings file, using the defaults\n", path)
+		return
+	}
+
+	settings, meta, err := loadSettings(path)
+	problems := 0
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, line)
+			problems++
+		}
+	}
+	for _, key := range settings.unknownKeys(meta) {
+		fmt.Fprintf(os.Stderr, "%s: unknown key %s\n", path, key)
+		problems++
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("%s: OK\n", path)
+}
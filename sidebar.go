@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jakeasaurus/lazytodo/todotxt"
+)
+
+// pane is a panel of the screen that can have focus
+type pane int
+
+const (
+	paneList pane = iota
+	paneSidebar
+	paneDetails
+)
+
+// paneOrder is the order the focus keys move through the panes in
+var paneOrder = []pane{paneSidebar, paneList, paneDetails}
+
+// sidebarStyle draws the project and context navigator
+var sidebarStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	Padding(0, 1)
+
+// sidebarEntry is a project or context in the sidebar, or all todos for
+// an empty name
+type sidebarEntry struct {
+	name string
+	open int
+	done int
+}
+
+// match reports whether a todo belongs to the entry
+func (e sidebarEntry) match(todo todotxt.Todo) bool {
+	switch {
+	case e.name == "":
+		return true
+	case e.name[0] == '+':
+		return slices.Contains(todo.Projects, e.name[1:])
+	default:
+		return slices.Contains(todo.Contexts, e.name[1:])
+	}
+}
+
+// count adds a todo to the entry's open or done count
+func (e *sidebarEntry) count(todo todotxt.Todo) {
+	if todo.Completed {
+		e.done++
+	} else {
+		e.open++
+	}
+}
+
+// sidebarEntries returns all todos, then every project, then every
+// context, with how many of their todos are open and done
+func sidebarEntries(todos todotxt.List) []sidebarEntry {
+	all := sidebarEntry{}
+	counts := map[string]*sidebarEntry{}
+	for _, todo := range todos {
+		all.count(todo)
+		for _, name := range append(prefixAll("+", todo.Projects), prefixAll("@", todo.Contexts)...) {
+			if counts[name] == nil {
+				counts[name] = &sidebarEntry{name: name}
+			}
+			counts[name].count(todo)
+		}
+	}
+
+	entries := []sidebarEntry{all}
+	for _, name := range sortedKeys(counts) {
+		if name[0] == '+' {
+			entries = append(entries, *counts[name])
+		}
+	}
+	for _, name := range sortedKeys(counts) {
+		if name[0] == '@' {
+			entries = append(entries, *counts[name])
+		}
+	}
+	return entries
+}
+
+// prefixAll returns names with prefix put in front of each
+func prefixAll(prefix string, names []string) []string {
+	prefixed := make([]string, len(names))
+	for i, name := range names {
+		prefixed[i] = prefix + name
+	}
+	return prefixed
+}
+
+// sidebarWidth returns the columns the sidebar takes, the gap after it
+// included. It is hidden on compact screens or when turned off.
+func (m Model) sidebarWidth() int {
+	if m.compact() || m.settings.Layout.SidebarWidth == 0 {
+		return 0
+	}
+	return m.settings.Layout.SidebarWidth + 1
+}
+
+// sidebarIndex returns the position of the selected entry
+func (m Model) sidebarIndex(entries []sidebarEntry) int {
+	for i, entry := range entries {
+		if entry.name == m.sidebarFilter {
+			return i
+		}
+	}
+	return 0
+}
+
+// moveSidebar selects the entry step entries away
+func (m *Model) moveSidebar(step int) {
+	entries := sidebarEntries(m.todoManager.GetTodos())
+	m.pickSidebar(entries, m.sidebarIndex(entries)+step)
+}
+
+// pickSidebar selects entry i, clamped to the entries, and narrows the
+// todo list to it
+func (m *Model) pickSidebar(entries []sidebarEntry, i int) {
+	i = min(max(i, 0), len(entries)-1)
+	m.sidebarFilter = entries[i].name
+	m.updateTitle()
+	m.refreshList()
+	m.list.Select(0)
+}
+
+// cycleFocus moves the focus step panes along, skipping hidden panes
+func (m *Model) cycleFocus(step int) {
+	i := slices.Index(paneOrder, m.focus)
+	for {
+		i = (i + step + len(paneOrder)) % len(paneOrder)
+		next := paneOrder[i]
+		if (next == paneSidebar && m.sidebarWidth() == 0) || (next == paneDetails && m.compact()) {
+			continue
+		}
+		m.focus = next
+		m.detailsOffset = 0
+		return
+	}
+}
+
+// paneStyle returns style with a thick border in the selected color when
+// the pane has focus
+func (m Model) paneStyle(style lipgloss.Style, p pane) lipgloss.Style {
+	if m.focus != p {
+		return style
+	}
+	return style.Border(lipgloss.ThickBorder()).BorderForeground(selectedStyle.GetForeground())
+}
+
+// sidebarView renders the sidebar at the given height, border included,
+// scrolled so the selected entry is in sight
+func (m Model) sidebarView(height int) string {
+	entries := sidebarEntries(m.todoManager.GetTodos())
+	selected := m.sidebarIndex(entries)
+	width := m.settings.Layout.SidebarWidth - 4
+
+	var lines []string
+	selectedLine := 0
+	for i, entry := range entries {
+		project := strings.HasPrefix(entry.name, "+")
+		context := strings.HasPrefix(entry.name, "@")
+		switch {
+		case project && i == 1:
+			lines = append(lines, "", lipgloss.NewStyle().Bold(true).Render("Projects"))
+		case context && !strings.HasPrefix(entries[i-1].name, "@"):
+			lines = append(lines, "", lipgloss.NewStyle().Bold(true).Render("Contexts"))
+		}
+
+		// The name on the left, open/done counts on the right
+		label := entry.name
+		if label == "" {
+			label = "All"
+		}
+		counts := fmt.Sprintf("%d/%d", entry.open, entry.done)
+		label = truncate(label, width-len(counts)-3)
+		gap := strings.Repeat(" ", max(width-2-lipgloss.Width(label)-len(counts), 1))
+
+		var line string
+		switch {
+		case i == selected:
+			selectedLine = len(lines)
+			line = selectedStyle.Bold(true).Render("▸ " + label + gap + counts)
+		case project:
+			line = "  " + projectStyle.Render(label) + gap + helpStyle.Render(counts)
+		case context:
+			line = "  " + contextStyle.Render(label) + gap + helpStyle.Render(counts)
+		default:
+			line = "  " + label + gap + helpStyle.Render(counts)
+		}
+		lines = append(lines, line)
+	}
+
+	visible := max(height-2, 1)
+	first := max(selectedLine-visible+1, 0)
+	lines = lines[first:min(first+visible, len(lines))]
+	return m.paneStyle(sidebarStyle, paneSidebar).
+		Width(m.settings.Layout.SidebarWidth - 2).
+		Height(visible).
+		Render(strings.Join(lines, "\n"))
+}
+
+// truncate shortens text to width columns, marking the cut with …
+func truncate(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
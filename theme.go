@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme colors the UI, keyed by the names in colorRoles
+type Theme map[string]themeColor
+
+// themeColor is a color for light and dark terminals. The terminal's
+// background picks between them; an empty color means no color at all.
+type themeColor struct {
+	Light string
+	Dark  string
+}
+
+// UnmarshalTOML accepts a single color for both backgrounds, or a table
+// such as { light = "#D7005F", dark = "#FF5F87" }
+func (c *themeColor) UnmarshalTOML(data any) error {
+	switch value := data.(type) {
+	case string:
+		*c = themeColor{Light: value, Dark: value}
+	case map[string]any:
+		light, _ := value["light"].(string)
+		dark, _ := value["dark"].(string)
+		if light == "" && dark == "" {
+			return fmt.Errorf("expected light and dark colors, got %v", data)
+		}
+		if light == "" {
+			light = dark
+		}
+		if dark == "" {
+			dark = light
+		}
+		*c = themeColor{Light: light, Dark: dark}
+	default:
+		return fmt.Errorf("expected a color, got %v", data)
+	}
+	return nil
+}
+
+// color returns the lipgloss color for both backgrounds
+func (c themeColor) color() lipgloss.TerminalColor {
+	switch {
+	case c.Light == "" && c.Dark == "":
+		return lipgloss.NoColor{}
+	case c.Light == c.Dark:
+		return lipgloss.Color(c.Dark)
+	}
+	return lipgloss.AdaptiveColor{Light: c.Light, Dark: c.Dark}
+}
+
+// valid reports whether both colors are hex colors or ANSI color numbers
+func (c themeColor) valid() bool {
+	return validColor(c.Light) && validColor(c.Dark)
+}
+
+// colorRoles maps the names of theme colors to the styles they color
+var colorRoles = map[string]func(lipgloss.TerminalColor){
+	"title":            func(c lipgloss.TerminalColor) { titleStyle = titleStyle.Foreground(c) },
+	"title_background": func(c lipgloss.TerminalColor) { titleStyle = titleStyle.Background(c) },
+	"status":           func(c lipgloss.TerminalColor) { statusMessageStyle = lipgloss.NewStyle().Foreground(c).Render },
+	"list_border":      func(c lipgloss.TerminalColor) { todoListStyle = todoListStyle.BorderForeground(c) },
+	"details_border":   func(c lipgloss.TerminalColor) { detailsStyle = detailsStyle.BorderForeground(c) },
+	"sidebar_border":   func(c lipgloss.TerminalColor) { sidebarStyle = sidebarStyle.BorderForeground(c) },
+	"input_border":     func(c lipgloss.TerminalColor) { inputStyle = inputStyle.BorderForeground(c) },
+	"selected":         func(c lipgloss.TerminalColor) { selectedStyle = selectedStyle.Foreground(c).BorderForeground(c) },
+	"help":             func(c lipgloss.TerminalColor) { helpStyle = helpStyle.Foreground(c) },
+	"completed":        func(c lipgloss.TerminalColor) { completedStyle = completedStyle.Foreground(c) },
+	"done":             func(c lipgloss.TerminalColor) { doneStyle = doneStyle.Foreground(c) },
+	"pending":          func(c lipgloss.TerminalColor) { pendingStyle = pendingStyle.Foreground(c) },
+	"priority":         func(c lipgloss.TerminalColor) { priorityStyle = priorityStyle.Foreground(c) },
+	"project":          func(c lipgloss.TerminalColor) { projectStyle = projectStyle.Foreground(c) },
+	"context":          func(c lipgloss.TerminalColor) { contextStyle = contextStyle.Foreground(c) },
+	"tag":              func(c lipgloss.TerminalColor) { tagStyle = tagStyle.Foreground(c) },
+	"overdue":          func(c lipgloss.TerminalColor) { overdueStyle = overdueStyle.Foreground(c) },
+	"due_today":        func(c lipgloss.TerminalColor) { dueTodayStyle = dueTodayStyle.Foreground(c) },
+	"due_soon":         func(c lipgloss.TerminalColor) { dueSoonStyle = dueSoonStyle.Foreground(c) },
+	"future":           func(c lipgloss.TerminalColor) { futureStyle = futureStyle.Foreground(c) },
+	"match":            func(c lipgloss.TerminalColor) { matchStyle = matchStyle.Foreground(c) },
+}
+
+// monochrome is set while the UI is drawn without any color
+var monochrome bool
+
+// monochromeTheme is the name of the theme that uses no color at all,
+// also picked whenever NO_COLOR is set
+const monochromeTheme = "monochrome"
+
+// bundledThemes are the themes that ship with lazytodo. Every color theme
+// sets every role, and user themes start from the default one.
+var bundledThemes = map[string]Theme{
+	// default is the original synthwave look, with darker variants of
+	// the accent colors on light terminals
+	"default": {
+		"title":            {"#FAFAFA", "#FAFAFA"},
+		"title_background": {"#7D56F4", "#7D56F4"},
+		"status":           {"#00875F", "#04B575"},
+		"list_border":      {"#874BFD", "#874BFD"},
+		"details_border":   {"#F25D94", "#F25D94"},
+		"sidebar_border":   {"#874BFD", "#874BFD"},
+		"input_border":     {"#D7008F", "#FF7CCB"},
+		"selected":         {"#D700D7", "#EE6FF8"},
+		"help":             {"#6C6C6C", "#626262"},
+		"completed":        {"#8A8A8A", "#626262"},
+		"done":             {"#00875F", "#04B575"},
+		"pending":          {"#AF5F00", "#FFB86C"},
+		"priority":         {"#D7005F", "#FF5F87"},
+		"project":          {"#005FD7", "#5FAFFF"},
+		"context":          {"#D75F00", "#FFAF5F"},
+		"tag":              {"#8700D7", "#AF87FF"},
+		"overdue":          {"#D70000", "#FF0000"},
+		"due_today":        {"#D75F00", "#FF8700"},
+		"due_soon":         {"#AF8700", "#FFD75F"},
+		"future":           {"#A8A8A8", "#4E4E4E"},
+		"match":            {"#D7AF00", "#FFFF5F"},
+	},
+	// solarized follows Ethan Schoonover's palette, base01/base1 for text
+	// and the same accents on both backgrounds
+	"solarized": {
+		"title":            {"#FDF6E3", "#002B36"},
+		"title_background": {"#268BD2", "#268BD2"},
+		"status":           {"#859900", "#859900"},
+		"list_border":      {"#93A1A1", "#586E75"},
+		"details_border":   {"#93A1A1", "#586E75"},
+		"sidebar_border":   {"#93A1A1", "#586E75"},
+		"input_border":     {"#6C71C4", "#6C71C4"},
+		"selected":         {"#D33682", "#D33682"},
+		"help":             {"#93A1A1", "#586E75"},
+		"completed":        {"#93A1A1", "#586E75"},
+		"done":             {"#859900", "#859900"},
+		"pending":          {"#B58900", "#B58900"},
+		"priority":         {"#DC322F", "#DC322F"},
+		"project":          {"#268BD2", "#268BD2"},
+		"context":          {"#2AA198", "#2AA198"},
+		"tag":              {"#6C71C4", "#6C71C4"},
+		"overdue":          {"#DC322F", "#DC322F"},
+		"due_today":        {"#CB4B16", "#CB4B16"},
+		"due_soon":         {"#B58900", "#B58900"},
+		"future":           {"#93A1A1", "#586E75"},
+		"match":            {"#CB4B16", "#CB4B16"},
+	},
+	// dracula is a dark-only theme
+	"dracula": {
+		"title":            {"#282A36", "#282A36"},
+		"title_background": {"#BD93F9", "#BD93F9"},
+		"status":           {"#50FA7B", "#50FA7B"},
+		"list_border":      {"#6272A4", "#6272A4"},
+		"details_border":   {"#FF79C6", "#FF79C6"},
+		"sidebar_border":   {"#6272A4", "#6272A4"},
+		"input_border":     {"#BD93F9", "#BD93F9"},
+		"selected":         {"#FF79C6", "#FF79C6"},
+		"help":             {"#6272A4", "#6272A4"},
+		"completed":        {"#6272A4", "#6272A4"},
+		"done":             {"#50FA7B", "#50FA7B"},
+		"pending":          {"#FFB86C", "#FFB86C"},
+		"priority":         {"#FF5555", "#FF5555"},
+		"project":          {"#8BE9FD", "#8BE9FD"},
+		"context":          {"#FFB86C", "#FFB86C"},
+		"tag":              {"#BD93F9", "#BD93F9"},
+		"overdue":          {"#FF5555", "#FF5555"},
+		"due_today":        {"#FFB86C", "#FFB86C"},
+		"due_soon":         {"#F1FA8C", "#F1FA8C"},
+		"future":           {"#6272A4", "#6272A4"},
+		"match":            {"#F1FA8C", "#F1FA8C"},
+	},
+	// monochrome leaves every color out and relies on bold, faint and
+	// strikethrough text
+	monochromeTheme: {},
+}
+
+// themePath returns where a user theme file lives
+func themePath(name string) string {
+	return filepath.Join(filepath.Dir(settingsPath()), "themes", name+".toml")
+}
+
+// themeNames returns the bundled themes and the user theme files
+func themeNames() []string {
+	names := map[string]bool{}
+	for name := range bundledThemes {
+		names[name] = true
+	}
+	matches, _ := filepath.Glob(themePath("*"))
+	for _, match := range matches {
+		names[filepath.Base(match[:len(match)-len(".toml")])] = true
+	}
+	return sortedKeys(names)
+}
+
+// loadTheme returns the named theme. A user theme file wins over a
+// bundled theme of the same name, and starts from the default theme so it
+// only needs the colors it changes.
+func loadTheme(name string) (Theme, error) {
+	theme := maps.Clone(bundledThemes["default"])
+	if _, err := toml.DecodeFile(themePath(name), &theme); err == nil {
+		return theme, theme.validate("theme " + name + ": ")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("theme %s: %w", name, err)
+	}
+
+	bundled, ok := bundledThemes[name]
+	if !ok {
+		return nil, fmt.Errorf("ui.theme %q doesn't exist (available: %v)", name, themeNames())
+	}
+	return bundled, nil
+}
+
+// validate checks that every color is usable and every role is known.
+// prefix says where the colors came from.
+func (t Theme) validate(prefix string) error {
+	var errs []error
+	for _, name := range sortedKeys(t) {
+		if _, ok := colorRoles[name]; !ok {
+			errs = append(errs, fmt.Errorf("%sunknown color %s", prefix, name))
+		} else if !t[name].valid() {
+			errs = append(errs, fmt.Errorf("%s%s must be a hex color like #FF5F87 or an ANSI color number", prefix, name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyTheme colors every style from the theme. overrides are the
+// individual [colors] from the settings file. In monochrome every color is
+// left out, and the title is drawn in reverse video to stay visible.
+func applyTheme(theme Theme, overrides Theme, noColors bool) {
+	monochrome = noColors
+	roles := make([]string, 0, len(colorRoles))
+	for name := range colorRoles {
+		roles = append(roles, name)
+	}
+	sort.Strings(roles)
+
+	for _, name := range roles {
+		color, ok := overrides[name]
+		if !ok {
+			color = theme[name]
+		}
+		if monochrome {
+			color = themeColor{}
+		}
+		colorRoles[name](color.color())
+	}
+	if monochrome {
+		titleStyle = titleStyle.Reverse(true)
+	}
+}
+
+// noColor reports whether the user asked for no color, following
+// https://no-color.org
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
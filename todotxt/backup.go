@@ -0,0 +1,184 @@
+package todotxt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultBackupCount is how many rotating backups are kept next to the
+// todo file when the config doesn't say otherwise
+const defaultBackupCount = 3
+
+// Backup describes one rotating backup of the todo file
+type Backup struct {
+	Index   int
+	Path    string
+	ModTime time.Time
+	Lines   int
+}
+
+// backupPath returns the path of the nth backup, todo.txt.bak.1 being the
+// most recent
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// writeFileAtomic writes data to a temp file in the same directory, syncs
+// it and renames it over path, so a crash or full disk never leaves a
+// truncated file behind. Symlinks are followed so dotfile setups survive.
+func writeFileAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// Clean up the temp file on any failure below
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself; not every platform can sync a directory
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// rotateBackups shifts todo.txt.bak.N down by one and copies the current
+// file to todo.txt.bak.1, keeping at most count backups
+func rotateBackups(path string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	os.Remove(backupPath(path, count))
+	for i := count - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(path, i), backupPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupPath(path, 1), data)
+}
+
+// Backups lists the rotating backups of the file, newest first
+func (s *FileStore) Backups() ([]Backup, error) {
+	matches, err := filepath.Glob(s.path + ".bak.*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, match := range matches {
+		var index int
+		if _, err := fmt.Sscanf(match[len(s.path):], ".bak.%d", &index); err != nil || backupPath(s.path, index) != match {
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(match)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Index:   index,
+			Path:    match,
+			ModTime: info.ModTime(),
+			Lines:   bytes.Count(data, []byte("\n")),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Index < backups[j].Index
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the file with the nth backup. The current file
+// is rotated into the backups first, so a restore can itself be undone.
+func (s *FileStore) RestoreBackup(n int) error {
+	data, err := os.ReadFile(backupPath(s.path, n))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("backup %d: %w", n, ErrBackupNotFound)
+		}
+		return err
+	}
+
+	if err := rotateBackups(s.path, s.backups); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// Backups lists the rotating backups of the todo list, newest first
+func (tm *TodoManager) Backups() ([]Backup, error) {
+	store, ok := tm.store.(BackupStore)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", tm.store.Location(), ErrNoBackups)
+	}
+	return store.Backups()
+}
+
+// Restore replaces the todo list with the nth backup. The current list is
+// rotated into the backups first, so a restore can itself be undone.
+func (tm *TodoManager) Restore(n int) error {
+	store, ok := tm.store.(BackupStore)
+	if !ok {
+		return fmt.Errorf("%s: %w", tm.store.Location(), ErrNoBackups)
+	}
+
+	unlock, err := tm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := store.RestoreBackup(n); err != nil {
+		return err
+	}
+	if err := tm.Load(); err != nil {
+		return err
+	}
+	tm.history.reset(tm.todos.Lines())
+	return nil
+}
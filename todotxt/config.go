@@ -0,0 +1,215 @@
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Config holds the todo.txt settings lazytodo understands
+type Config struct {
+	TodoFile string
+	DoneFile string
+	TodoDir  string
+	// Backups is how many rotating backups of the todo file to keep
+	Backups int
+	// Store names the storage backend the todo and done files are kept
+	// in, as registered with RegisterStore
+	Store string
+	// DefaultAction is the command todo.sh runs when given none
+	DefaultAction string
+	// PreserveLineNumbers leaves a blank line behind when a todo is
+	// deleted, so the other todos keep their IDs until the next archive
+	PreserveLineNumbers bool
+	// DateOnAdd prefixes new todos with today's date. todo.sh defaults to
+	// off, but lazytodo has always dated new todos, so it defaults to on.
+	DateOnAdd bool
+}
+
+// ConfigPaths returns the places todo.sh looks for its config file, in
+// the order they are tried
+func ConfigPaths() []string {
+	homeDir, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return []string{
+		filepath.Join(homeDir, ".todo", "config"),
+		filepath.Join(homeDir, "todo.cfg"),
+		filepath.Join(homeDir, ".todo.cfg"),
+		filepath.Join(configHome, "todo", "config"),
+	}
+}
+
+// LoadConfig reads the todo.txt configuration. An explicit path, as given
+// to `-d`, wins over TODOTXT_CFG_FILE, which wins over the first of
+// ConfigPaths that exists. A config file that was asked for by name must
+// be readable; with none found the defaults are used.
+func LoadConfig(path string) (Config, error) {
+	homeDir, _ := os.UserHomeDir()
+
+	// Default configuration
+	config := Config{
+		TodoFile:            "todo.txt",
+		DoneFile:            "done.txt",
+		TodoDir:             homeDir,
+		Backups:             defaultBackupCount,
+		Store:               defaultStore,
+		PreserveLineNumbers: true,
+		DateOnAdd:           true,
+	}
+
+	if path == "" {
+		path = os.Getenv("TODOTXT_CFG_FILE")
+	}
+	explicit := path != ""
+	if !explicit {
+		for _, candidate := range ConfigPaths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	vars := map[string]string{}
+	if path != "" {
+		parsed, err := parseConfigFile(path)
+		if err != nil && (explicit || !os.IsNotExist(err)) {
+			return config, fmt.Errorf("reading config: %w", err)
+		}
+		if err == nil {
+			vars = parsed
+		}
+	}
+
+	// Like todo.sh, TODOTXT_ settings in the environment override the file
+	for _, key := range []string{"TODOTXT_DEFAULT_ACTION", "TODOTXT_PRESERVE_LINE_NUMBERS", "TODOTXT_DATE_ON_ADD"} {
+		if value, ok := os.LookupEnv(key); ok {
+			vars[key] = value
+		}
+	}
+
+	for key, value := range vars {
+		switch key {
+		case "TODO_DIR":
+			config.TodoDir = value
+		case "TODO_FILE":
+			config.TodoFile = value
+		case "DONE_FILE":
+			config.DoneFile = value
+		case "LAZYTODO_BACKUPS":
+			if count, err := strconv.Atoi(value); err == nil && count >= 0 {
+				config.Backups = count
+			}
+		case "LAZYTODO_STORE":
+			config.Store = value
+		case "TODOTXT_DEFAULT_ACTION":
+			config.DefaultAction = value
+		case "TODOTXT_PRESERVE_LINE_NUMBERS":
+			if preserve, err := strconv.ParseBool(value); err == nil {
+				config.PreserveLineNumbers = preserve
+			}
+		case "TODOTXT_DATE_ON_ADD":
+			if dateOnAdd, err := strconv.ParseBool(value); err == nil {
+				config.DateOnAdd = dateOnAdd
+			}
+		}
+	}
+
+	// If TODO_FILE is not absolute, make it relative to TODO_DIR
+	if !filepath.IsAbs(config.TodoFile) {
+		config.TodoFile = filepath.Join(config.TodoDir, config.TodoFile)
+	}
+
+	// If DONE_FILE is not absolute, make it relative to TODO_DIR
+	if !filepath.IsAbs(config.DoneFile) {
+		config.DoneFile = filepath.Join(config.TodoDir, config.DoneFile)
+	}
+
+	return config, nil
+}
+
+// dirnameRegex matches the $(dirname "$0") the config file todo.sh ships
+// with uses for TODO_DIR, in its backtick and unquoted forms too
+var dirnameRegex = regexp.MustCompile("\\$\\(dirname \"?\\$0\"?\\)|`dirname \"?\\$0\"?`")
+
+// parseConfigFile reads the key=value assignments of a todo.sh config
+// file. It is a shell script, but in practice only uses assignments with
+// variable references, so those are all that is understood. The one
+// command substitution todo.sh's own config file uses, $(dirname "$0"),
+// stands for the config file's directory; any other is an error.
+func parseConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip comments and empty lines
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Look for export statements
+		if strings.HasPrefix(line, "export ") {
+			line = strings.TrimPrefix(line, "export ")
+		}
+
+		// Parse key=value pairs
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		// Remove quotes if present. As in the shell, single quotes keep
+		// the value literal.
+		if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			vars[key] = value[1 : len(value)-1]
+			continue
+		}
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+
+		// todo.sh's $0 is the script next to its config file, so its
+		// directory is the config file's
+		value = dirnameRegex.ReplaceAllLiteralString(value, configDir(path))
+		if strings.Contains(value, "$(") || strings.Contains(value, "`") {
+			return nil, fmt.Errorf("%s: %s uses a shell command, which lazytodo can't run; set it to a path instead", path, key)
+		}
+
+		// Expand variables, so TODO_FILE="$TODO_DIR/todo.txt" can refer
+		// to a TODO_DIR set earlier in the file
+		value = os.Expand(value, func(name string) string {
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			return os.Getenv(name)
+		})
+
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
+
+// configDir returns the absolute directory of a config file
+func configDir(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Dir(path)
+}
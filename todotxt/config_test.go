@@ -0,0 +1,38 @@
+package todotxt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigDirnameOfScript(t *testing.T) {
+	dir := t.TempDir()
+	for _, todoDir := range []string{`$(dirname "$0")`, `"$(dirname "$0")"`, `$(dirname $0)`, "`dirname \"$0\"`"} {
+		path := filepath.Join(dir, "config")
+		data := "export TODO_DIR=" + todoDir + "\nexport TODO_FILE=\"$TODO_DIR/todo.txt\"\n"
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("TODO_DIR=%s: %v", todoDir, err)
+		}
+		if want := filepath.Join(dir, "todo.txt"); config.TodoFile != want {
+			t.Errorf("TODO_DIR=%s: TodoFile = %q, want %q", todoDir, config.TodoFile, want)
+		}
+	}
+}
+
+func TestLoadConfigOtherCommandsAreErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	data := "export TODO_DIR=\"$HOME/todo\"\nexport TODO_FILE=$(cat ~/.todo-file)\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "TODO_FILE") {
+		t.Errorf("LoadConfig = %v, want an error naming TODO_FILE", err)
+	}
+}
@@ -0,0 +1,39 @@
+package todotxt
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no todo has the given ID, including
+	// the blank line a deleted todo leaves behind
+	ErrNotFound = errors.New("todo not found")
+	// ErrInvalidTag is returned when a tag key or value can't be written
+	// as a single key:value word
+	ErrInvalidTag = errors.New("invalid tag")
+	// ErrInvalidPriority is returned by SetPriority for anything but a
+	// single letter from A to Z
+	ErrInvalidPriority = errors.New("invalid priority")
+	// ErrEmptyTodo is returned by AddTodo and UpdateTodo for text that is
+	// empty once sanitized
+	ErrEmptyTodo = errors.New("todo text is empty")
+	// ErrConflict is returned by Save when the todo file was changed on
+	// disk in a way that can't be merged with the in-memory changes
+	ErrConflict = errors.New("todo file changed on disk and conflicts with your change")
+	// ErrLocked is returned when another process holds the todo file's
+	// lock for longer than the lock timeout
+	ErrLocked = errors.New("todo file is locked by another process")
+	// ErrNothingToUndo is returned by Undo when the history is empty
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when nothing was undone
+	ErrNothingToRedo = errors.New("nothing to redo")
+	// ErrBackupNotFound is returned by Restore for a missing backup
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrInvalidQuery is returned by ParseQuery for a malformed query
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrNoBackups is returned by Backups and Restore when the storage
+	// backend doesn't keep backups
+	ErrNoBackups = errors.New("storage backend keeps no backups")
+	// ErrUnknownStore is returned by OpenStore for an unregistered backend
+	ErrUnknownStore = errors.New("unknown storage backend")
+
+	errHistoryDiverged = errors.New("the todos it touched have changed since")
+)
@@ -0,0 +1,140 @@
+package todotxt
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// watchInterval is how often FileStore.Watch checks the file for changes
+const watchInterval = time.Second
+
+// FileStore keeps a todo list in a local todo.txt file. Saves are atomic
+// and keep rotating backups next to the file.
+type FileStore struct {
+	path    string
+	backups int
+	// newline is the line ending the file used when it was last loaded
+	newline string
+}
+
+// NewFileStore creates a FileStore for the file at path that keeps the
+// given number of backups
+func NewFileStore(path string, backups int) *FileStore {
+	return &FileStore{path: path, backups: backups, newline: "\n"}
+}
+
+// Load reads the file
+func (s *FileStore) Load() ([]string, Version, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	s.newline = lineEnding(data)
+	return splitLines(data), versionOf(data), nil
+}
+
+// Save replaces the file atomically, after rotating the current version
+// into the backups
+func (s *FileStore) Save(lines []string) (Version, error) {
+	data := joinLines(lines, s.newline)
+	if err := rotateBackups(s.path, s.backups); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return "", err
+	}
+	return versionOf(data), nil
+}
+
+// Append adds lines to the end of the file without rewriting it
+func (s *FileStore) Append(lines []string) error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	// Don't glue the first new line onto an unterminated last line
+	if info, err := file.Stat(); err == nil && info.Size() > 0 && !endsWithNewline(s.path) {
+		if _, err := file.WriteString(s.newline); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if _, err := file.Write(joinLines(lines, s.newline)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Watch polls the file's size and modification time. Polling keeps
+// lazytodo free of platform-specific notification APIs, and a todo file
+// is cheap to stat.
+func (s *FileStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	last, _ := os.Stat(s.path)
+
+	go func() {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, _ := os.Stat(s.path)
+				if !sameFileInfo(last, info) {
+					last = info
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// sameFileInfo reports whether two stats look like the same version of a
+// file; a nil stat stands for a missing file
+func sameFileInfo(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// Location returns the path of the file
+func (s *FileStore) Location() string {
+	return s.path
+}
+
+// endsWithNewline reports whether the file at path ends with a newline
+func endsWithNewline(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+
+	buf := make([]byte, 1)
+	if _, err := file.ReadAt(buf, info.Size()-1); err != nil {
+		return false
+	}
+	return buf[0] == '\n'
+}
@@ -0,0 +1,261 @@
+package todotxt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// historyLimit caps how many changes can be undone
+const historyLimit = 50
+
+// change is one undoable change, kept as the lines it touched rather than
+// whole copies of the file
+type change struct {
+	Label string     `json:"label"`
+	Edits []lineEdit `json:"edits"`
+}
+
+// lineEdit replaces the lines Before, starting at line number Line, with
+// the lines After. Edits of a change are in line order and don't overlap,
+// and only the last one may change the number of lines, so Line is the
+// same in the file before and after the change.
+type lineEdit struct {
+	Line   int      `json:"line"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// diffLines returns the edits that turn before into after. Changes in
+// place and lines added at the end are recorded line by line; when lines
+// were removed, the span between the unchanged start and end is.
+func diffLines(before, after []string) []lineEdit {
+	var edits []lineEdit
+	if len(after) < len(before) {
+		start := 0
+		for start < len(after) && before[start] == after[start] {
+			start++
+		}
+		end := 0
+		for end < len(after)-start && before[len(before)-1-end] == after[len(after)-1-end] {
+			end++
+		}
+		// Keep the line after the removed ones in the edit, so reverting
+		// puts them back in front of it rather than at the end of the file
+		if end > 0 {
+			end--
+		}
+		return append(edits, lineEdit{
+			Line:   start + 1,
+			Before: before[start : len(before)-end],
+			After:  after[start : len(after)-end],
+		})
+	}
+
+	for i, line := range before {
+		if after[i] != line {
+			edits = append(edits, lineEdit{Line: i + 1, Before: []string{line}, After: []string{after[i]}})
+		}
+	}
+	if len(after) > len(before) {
+		edits = append(edits, lineEdit{Line: len(before) + 1, After: after[len(before):]})
+	}
+	return edits
+}
+
+// history holds the undo and redo stacks. It is kept in a small journal
+// file so it survives a restart.
+type history struct {
+	Undo []change `json:"undo"`
+	Redo []change `json:"redo"`
+	// Version is the todo file the journal was written against; a
+	// journal that no longer matches the file is stale and gets dropped
+	Version Version `json:"version"`
+
+	path string
+}
+
+// journalPath returns where the history for a todo file is kept. It lives
+// in the user cache dir rather than next to todo.txt, so it never ends up
+// in synced or git-tracked todo directories.
+func journalPath(todoFile string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	if abs, err := filepath.Abs(todoFile); err == nil {
+		todoFile = abs
+	}
+	sum := sha256.Sum256([]byte(todoFile))
+	return filepath.Join(cacheDir, "lazytodo", "history-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// linesVersion identifies a version of the todo file by its lines
+func linesVersion(lines []string) Version {
+	return versionOf(joinLines(lines, "\n"))
+}
+
+// loadHistory reads the journal for the current todo lines
+func loadHistory(path string, lines []string) *history {
+	h := &history{path: path, Version: linesVersion(lines)}
+	if path == "" {
+		return h
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	var saved history
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != h.Version {
+		return h
+	}
+	saved.path = path
+	return &saved
+}
+
+// save writes the journal. History is a convenience, so failures are
+// ignored rather than getting in the way of the change itself.
+func (h *history) save(lines []string) {
+	h.Version = linesVersion(lines)
+	if h.path == "" {
+		return
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return
+	}
+	writeFileAtomic(h.path, data)
+}
+
+// record adds a change to the undo stack and clears the redo stack. lines
+// is the todo file as saved, which has outside changes merged in as well.
+func (h *history) record(c change, lines []string) {
+	h.Undo = append(h.Undo, c)
+	if len(h.Undo) > historyLimit {
+		h.Undo = h.Undo[len(h.Undo)-historyLimit:]
+	}
+	h.Redo = nil
+	h.save(lines)
+}
+
+// reset forgets the whole history, for changes that can't be undone
+func (h *history) reset(lines []string) {
+	h.Undo = nil
+	h.Redo = nil
+	h.save(lines)
+}
+
+// commit saves a change and records it so it can be undone. Only the
+// change itself is recorded, not outside changes the save merges in, so
+// undoing it leaves those alone.
+func (tm *TodoManager) commit(label string, before []string) error {
+	c := change{Label: label, Edits: diffLines(before, tm.todos.Lines())}
+	if err := tm.Save(); err != nil {
+		if errors.Is(err, ErrLocked) {
+			// Nothing was written, so drop the change rather than leave
+			// it half applied
+			tm.todos = parseLines(before)
+			tm.nextID = len(before) + 1
+		}
+		return err
+	}
+	tm.history.record(c, tm.todos.Lines())
+	return nil
+}
+
+// Undo reverts the most recent change and returns its label
+func (tm *TodoManager) Undo() (string, error) {
+	if len(tm.history.Undo) == 0 {
+		return "", ErrNothingToUndo
+	}
+	c := tm.history.Undo[len(tm.history.Undo)-1]
+	err := tm.replay(c.Edits, true)
+	if err != nil && !errors.Is(err, ErrConflict) {
+		return "", fmt.Errorf("can't undo %s: %w", c.Label, err)
+	}
+	// A save conflict still leaves the change applied in memory, waiting
+	// for the user to resolve it
+	tm.history.Undo = tm.history.Undo[:len(tm.history.Undo)-1]
+	tm.history.Redo = append(tm.history.Redo, c)
+	tm.history.save(tm.todos.Lines())
+	return c.Label, err
+}
+
+// Redo re-applies the most recently undone change and returns its label
+func (tm *TodoManager) Redo() (string, error) {
+	if len(tm.history.Redo) == 0 {
+		return "", ErrNothingToRedo
+	}
+	c := tm.history.Redo[len(tm.history.Redo)-1]
+	err := tm.replay(c.Edits, false)
+	if err != nil && !errors.Is(err, ErrConflict) {
+		return "", fmt.Errorf("can't redo %s: %w", c.Label, err)
+	}
+	// A save conflict still leaves the change applied in memory, waiting
+	// for the user to resolve it
+	tm.history.Redo = tm.history.Redo[:len(tm.history.Redo)-1]
+	tm.history.Undo = append(tm.history.Undo, c)
+	tm.history.save(tm.todos.Lines())
+	return c.Label, err
+}
+
+// replay applies edits to the current todos, or reverts them, and saves
+// the todos. Going through mergeTodos means edits made since then, in
+// lazytodo or elsewhere, are kept.
+func (tm *TodoManager) replay(edits []lineEdit, revert bool) error {
+	// The lines being replaced are the base, and the lines replacing them
+	// our version. Lines a change added get IDs past every base line, so
+	// the merge appends them.
+	base := map[int]string{}
+	var target []Todo
+	var added []string
+	for _, edit := range edits {
+		from, to := edit.Before, edit.After
+		if revert {
+			from, to = to, from
+		}
+		for i, line := range from {
+			base[edit.Line+i] = line
+		}
+		for i, line := range to {
+			if i < len(from) {
+				target = append(target, Todo{ID: edit.Line + i, Raw: line})
+			} else {
+				added = append(added, line)
+			}
+		}
+	}
+	nextID := 1
+	for id := range base {
+		nextID = max(nextID, id+1)
+	}
+	for i, line := range added {
+		target = append(target, Todo{ID: nextID + i, Raw: line})
+	}
+
+	merged, err := mergeTodos(base, target, tm.todos.Lines())
+	if err != nil {
+		return errHistoryDiverged
+	}
+
+	previous := tm.todos
+	tm.todos = parseLines(merged)
+	tm.nextID = len(merged) + 1
+
+	if err := tm.Save(); err != nil {
+		if !errors.Is(err, ErrConflict) {
+			tm.todos = previous
+			tm.nextID = len(previous) + 1
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package todotxt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []string
+	}{
+		{"edit", []string{"a", "b", "c"}, []string{"a", "B", "c"}},
+		{"append", []string{"a"}, []string{"a", "b", "c"}},
+		{"edit and append", []string{"a", "b", "c"}, []string{"a", "B", "c", "d"}},
+		{"blank", []string{"a", "b"}, []string{"", "b"}},
+		{"remove", []string{"a", "b", "c", "d"}, []string{"a", "c", "d"}},
+		{"remove last", []string{"a", "b"}, []string{"a"}},
+		{"remove repeated", []string{"a", "a", "a"}, []string{"a", "a"}},
+		{"from empty", nil, []string{"a"}},
+		{"to empty", []string{"a", "b"}, nil},
+	}
+	for _, test := range tests {
+		edits := diffLines(test.before, test.after)
+		if got := applyEdits(test.before, edits, false); !slices.Equal(got, test.after) {
+			t.Errorf("%s: applying %+v gives %q, want %q", test.name, edits, got, test.after)
+		}
+		if got := applyEdits(test.after, edits, true); !slices.Equal(got, test.before) {
+			t.Errorf("%s: reverting %+v gives %q, want %q", test.name, edits, got, test.before)
+		}
+	}
+}
+
+// applyEdits applies or reverts edits to lines, last edit first so the
+// line numbers of the others still hold
+func applyEdits(lines []string, edits []lineEdit, revert bool) []string {
+	lines = slices.Clone(lines)
+	for i := len(edits) - 1; i >= 0; i-- {
+		from, to := edits[i].Before, edits[i].After
+		if revert {
+			from, to = to, from
+		}
+		start := edits[i].Line - 1
+		lines = slices.Replace(lines, start, start+len(from), to...)
+	}
+	return lines
+}
+
+func TestUndoRedo(t *testing.T) {
+	tm, store := newTestManager(t, "(A) first", "second due:2026-10-20", "third")
+	tm.SetDateOnAdd(false)
+	lines := func() []string {
+		lines, _, _ := store.Load()
+		return lines
+	}
+
+	var states [][]string
+	steps := []func() error{
+		func() error { return tm.AddTodo("fourth") },
+		func() error { return tm.UpdateTodo(3, "third edited") },
+		func() error { return tm.SetPriority(2, "B") },
+		func() error { return tm.RemoveTag(2, "due") },
+		func() error { return tm.ToggleComplete(1) },
+		func() error { return tm.DeleteTodo(4) },
+	}
+	for _, step := range steps {
+		states = append(states, lines())
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	final := lines()
+
+	for i := len(states) - 1; i >= 0; i-- {
+		if _, err := tm.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := lines(); !slices.Equal(got, states[i]) {
+			t.Fatalf("undo %d: got %q, want %q", i, got, states[i])
+		}
+	}
+	for range states {
+		if _, err := tm.Redo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := lines(); !slices.Equal(got, final) {
+		t.Errorf("redo all: got %q, want %q", got, final)
+	}
+}
+
+func TestUndoDeleteWithoutBlankLine(t *testing.T) {
+	tm, store := newTestManager(t, "first", "second", "third")
+	tm.SetPreserveLineNumbers(false)
+	if err := tm.DeleteTodo(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first", "second", "third"}
+	if got, _, _ := store.Load(); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUndoKeepsChangesMergedOnSave(t *testing.T) {
+	tm, store := newTestManager(t, "a", "b")
+	store.Set([]string{"a", "b", "ext"})
+	if err := tm.ToggleComplete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tm.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "ext"}
+	if got, _, _ := store.Load(); !slices.Equal(got, want) {
+		t.Errorf("undo: got %q, want %q", got, want)
+	}
+
+	if _, err := tm.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := store.Load(); len(got) != 3 || !strings.HasPrefix(got[0], "x ") || got[2] != "ext" {
+		t.Errorf("redo: got %q", got)
+	}
+}
+
+func TestUndoKeepsOutsideChanges(t *testing.T) {
+	tm, store := newTestManager(t, "first", "second")
+	if err := tm.UpdateTodo(2, "second edited"); err != nil {
+		t.Fatal(err)
+	}
+	store.Set([]string{"first changed elsewhere", "second edited", "added elsewhere"})
+	if err := tm.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tm.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first changed elsewhere", "second", "added elsewhere"}
+	if got, _, _ := store.Load(); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJournalOnlyKeepsChangedLines(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	var lines []string
+	for i := range 300 {
+		lines = append(lines, fmt.Sprintf("todo number %d with some text +project @context", i+1))
+	}
+	if err := os.WriteFile(path, joinLines(lines, "\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() *TodoManager {
+		tm, err := NewTodoManagerWithStores(NewFileStore(path, 0), NewMemoryStore("done.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tm := open()
+	for id := 1; id <= historyLimit; id++ {
+		if err := tm.SetPriority(id, "A"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(tm.history.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A whole copy of the file per change would be well over a megabyte
+	if info.Size() > 20_000 {
+		t.Errorf("journal for %d one-line changes is %d bytes", historyLimit, info.Size())
+	}
+
+	// The history survives a restart
+	label, err := open().Undo()
+	if err != nil || label != fmt.Sprintf("priority A on %q", "todo number 50 with some text +project @context") {
+		t.Errorf("undo after restart = %q, %v", label, err)
+	}
+}
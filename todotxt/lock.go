@@ -0,0 +1,80 @@
+package todotxt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultLockTimeout is how long a change waits for another process to
+// release the todo file before giving up with ErrLocked
+const defaultLockTimeout = 2 * time.Second
+
+// LockStore is implemented by stores that can take an advisory lock on the
+// list, so two processes never interleave a read-merge-write cycle
+type LockStore interface {
+	// TryLock takes the lock without waiting. It returns ErrLocked while
+	// another process holds it.
+	TryLock() (unlock func() error, err error)
+}
+
+// lockPath returns the lock file guarding path. The lock can't live on the
+// todo file itself: every atomic save renames a new file over it.
+func lockPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path + ".lock"
+}
+
+// TryLock takes an advisory lock on todo.txt.lock next to the file. Shell
+// scripts can share it with `flock todo.txt.lock todo.sh ...`.
+func (s *FileStore) TryLock() (func() error, error) {
+	file, err := os.OpenFile(lockPath(s.path), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// Closing the file releases the lock. The lock file itself stays, as
+	// removing it would let two processes lock different files.
+	return file.Close, nil
+}
+
+// SetLockTimeout changes how long changes wait for another process to
+// release the todo file. Interactive callers want this short; scripts can
+// afford to wait longer.
+func (tm *TodoManager) SetLockTimeout(timeout time.Duration) {
+	tm.lockTimeout = timeout
+}
+
+// lock takes the store's lock, retrying with backoff until the lock
+// timeout. It is reentrant, so Archive can hold the lock across the save
+// it makes. Stores without locking always succeed.
+func (tm *TodoManager) lock() (func(), error) {
+	store, ok := tm.store.(LockStore)
+	if !ok || tm.locked {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(tm.lockTimeout)
+	backoff := 10 * time.Millisecond
+	for {
+		unlock, err := store.TryLock()
+		if err == nil {
+			tm.locked = true
+			return func() {
+				tm.locked = false
+				unlock()
+			}, nil
+		}
+		if !errors.Is(err, ErrLocked) || time.Now().Add(backoff).After(deadline) {
+			return nil, err
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 250*time.Millisecond)
+	}
+}
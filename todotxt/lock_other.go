@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package todotxt
+
+import "os"
+
+// lockFile does nothing on platforms without file locking
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,559 @@
+package todotxt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortMode controls the order GetTodos returns todos in
+type SortMode int
+
+const (
+	// SortPriority orders by completion, then priority, then ID
+	SortPriority SortMode = iota
+	// SortDue is like SortPriority but puts the nearest due date first
+	// among todos with the same priority
+	SortDue
+	// SortFile keeps the order of the lines in the todo file
+	SortFile
+)
+
+// String returns a human readable name for the sort mode
+func (s SortMode) String() string {
+	switch s {
+	case SortDue:
+		return "due date"
+	case SortFile:
+		return "file order"
+	default:
+		return "priority"
+	}
+}
+
+// TodoManager keeps the todos of one todo list in sync with its Store.
+// Every change is saved right away.
+type TodoManager struct {
+	todos    List
+	store    Store
+	done     Store
+	nextID   int
+	sortMode SortMode
+	// base maps todo IDs to their lines as last loaded or saved, and
+	// version identifies that version of the list in the store
+	base    map[int]string
+	version Version
+	// history holds the undo and redo stacks
+	history *history
+	// lockTimeout is how long changes wait for the store's lock, and
+	// locked is set while this manager holds it
+	lockTimeout time.Duration
+	locked      bool
+	// preserveLines and dateOnAdd follow the todo.sh settings of the
+	// same names
+	preserveLines bool
+	dateOnAdd     bool
+}
+
+// NewTodoManager opens the todo and done files in config with the
+// configured storage backend and loads the todo list. A todo list that
+// can't be read is an error; one that doesn't exist yet starts out empty.
+func NewTodoManager(config Config) (*TodoManager, error) {
+	backend := config.Store
+	if backend == "" {
+		backend = defaultStore
+	}
+	store, err := OpenStore(backend, config.TodoFile, config.Backups)
+	if err != nil {
+		return nil, err
+	}
+	done, err := OpenStore(backend, config.DoneFile, 0)
+	if err != nil {
+		return nil, err
+	}
+	tm, err := NewTodoManagerWithStores(store, done)
+	if err != nil {
+		return nil, err
+	}
+	tm.SetPreserveLineNumbers(config.PreserveLineNumbers)
+	tm.SetDateOnAdd(config.DateOnAdd)
+	return tm, nil
+}
+
+// NewTodoManagerWithStores creates a TodoManager for a todo list and the
+// done list completed todos archive to, and loads the todo list
+func NewTodoManagerWithStores(store, done Store) (*TodoManager, error) {
+	tm := &TodoManager{
+		todos:         List{},
+		store:         store,
+		done:          done,
+		nextID:        1,
+		lockTimeout:   defaultLockTimeout,
+		preserveLines: true,
+		dateOnAdd:     true,
+	}
+
+	if err := tm.Load(); err != nil {
+		return nil, err
+	}
+
+	// A memory store forgets its list on exit, so its history has nothing
+	// to outlive
+	journal := journalPath(store.Location())
+	if _, ok := store.(*MemoryStore); ok {
+		journal = ""
+	}
+	tm.history = loadHistory(journal, tm.todos.Lines())
+	return tm, nil
+}
+
+// Load reads the todo list from the store, replacing the todos in memory
+// and any changes to them that weren't saved
+func (tm *TodoManager) Load() error {
+	lines, version, err := tm.store.Load()
+	if err != nil {
+		return err
+	}
+
+	tm.setLines(lines)
+	tm.version = version
+	return nil
+}
+
+// setLines replaces the todos with the given todo.txt lines. A todo's ID
+// is its line number, blank lines included, so IDs match `todo.sh ls`.
+func (tm *TodoManager) setLines(lines []string) {
+	tm.todos = parseLines(lines)
+	tm.base = map[int]string{}
+	for i, line := range lines {
+		tm.base[i+1] = line
+	}
+	tm.nextID = len(lines) + 1
+}
+
+// ChangedOnDisk reports whether the todo list was changed by something
+// else since it was last loaded or saved
+func (tm *TodoManager) ChangedOnDisk() (bool, error) {
+	_, version, err := tm.store.Load()
+	if err != nil {
+		return false, err
+	}
+	return version != tm.version, nil
+}
+
+// Watch signals when the todo list may have been changed by something
+// else, until ctx is done. Follow a signal up with ChangedOnDisk.
+func (tm *TodoManager) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return tm.store.Watch(ctx)
+}
+
+// Save writes the todos back to the store. A file store replaces the file
+// atomically and keeps the previous version as a rotating backup. If the
+// list changed since it was loaded, the changes are merged; ErrConflict is
+// returned when they can't be.
+func (tm *TodoManager) Save() error {
+	return tm.save(false)
+}
+
+// ForceSave writes the todos back to the store, overwriting any changes
+// made there since it was loaded
+func (tm *TodoManager) ForceSave() error {
+	return tm.save(true)
+}
+
+func (tm *TodoManager) save(force bool) error {
+	// Hold the lock from reading the current list to writing the merged
+	// one, so a concurrent writer can't slip in between
+	unlock, err := tm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lines := tm.todos.Lines()
+
+	merged := false
+	if !force {
+		current, version, err := tm.store.Load()
+		if err != nil {
+			return err
+		}
+		if version != "" && version != tm.version {
+			lines, err = mergeTodos(tm.base, tm.todos, current)
+			if err != nil {
+				return err
+			}
+			merged = true
+		}
+	}
+
+	version, err := tm.store.Save(lines)
+	if err != nil {
+		return err
+	}
+
+	if merged {
+		tm.setLines(lines)
+	} else {
+		tm.base = map[int]string{}
+		for _, todo := range tm.todos {
+			tm.base[todo.ID] = todo.Raw
+		}
+	}
+	tm.version = version
+	return nil
+}
+
+// GetTodos returns the todos in display order. The todos are kept in file
+// order internally, so sorting never reorders the todo file itself.
+func (tm *TodoManager) GetTodos() List {
+	todos := make(List, 0, len(tm.todos))
+	for _, todo := range tm.todos {
+		if !todo.IsBlank() {
+			todos = append(todos, todo)
+		}
+	}
+
+	if tm.sortMode == SortFile {
+		return todos
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		if todos[i].Completed != todos[j].Completed {
+			return !todos[i].Completed
+		}
+
+		if todos[i].Priority != todos[j].Priority {
+			if todos[i].Priority == "" {
+				return false
+			}
+			if todos[j].Priority == "" {
+				return true
+			}
+			return todos[i].Priority < todos[j].Priority
+		}
+
+		if tm.sortMode == SortDue {
+			dueI, okI := todos[i].DueDate()
+			dueJ, okJ := todos[j].DueDate()
+			if okI != okJ {
+				return okI
+			}
+			if okI && !dueI.Equal(dueJ) {
+				return dueI.Before(dueJ)
+			}
+		}
+
+		return todos[i].ID < todos[j].ID
+	})
+
+	return todos
+}
+
+// GetDoneTodos reads the todos archived to the done list, in file order
+func (tm *TodoManager) GetDoneTodos() (List, error) {
+	lines, _, err := tm.done.Load()
+	if err != nil {
+		return nil, err
+	}
+	return parseLines(lines), nil
+}
+
+// FilePath returns the location of the todo list, such as its file path
+func (tm *TodoManager) FilePath() string {
+	return tm.store.Location()
+}
+
+// DoneFile returns the location of the done list completed todos archive to
+func (tm *TodoManager) DoneFile() string {
+	return tm.done.Location()
+}
+
+// SetSortMode changes the order GetTodos returns todos in
+func (tm *TodoManager) SetSortMode(mode SortMode) {
+	tm.sortMode = mode
+}
+
+// SortMode returns the current sort mode
+func (tm *TodoManager) SortMode() SortMode {
+	return tm.sortMode
+}
+
+// SetPreserveLineNumbers controls whether DeleteTodo leaves a blank line
+// behind, as todo.sh does by default, or removes the line and renumbers
+// the todos after it
+func (tm *TodoManager) SetPreserveLineNumbers(preserve bool) {
+	tm.preserveLines = preserve
+}
+
+// SetDateOnAdd controls whether AddTodo gives new todos today's date as
+// their creation date
+func (tm *TodoManager) SetDateOnAdd(dateOnAdd bool) {
+	tm.dateOnAdd = dateOnAdd
+}
+
+// AddTodo appends a todo to the list and saves it. Unless turned off with
+// SetDateOnAdd, a todo without a creation date gets today's. Text that is
+// empty once escape sequences and control characters are stripped is an
+// error.
+func (tm *TodoManager) AddTodo(text string) error {
+	text = sanitizeInput(text)
+	if text == "" {
+		return ErrEmptyTodo
+	}
+
+	before := tm.todos.Lines()
+	todo := Parse(tm.nextID, text)
+	// The date goes after a typed "(A)", where todo.sh puts it
+	if tm.dateOnAdd && todo.CreatedDate == "" && !todo.Completed {
+		todo.CreatedDate = time.Now().Format("2006-01-02")
+		todo = Parse(todo.ID, Format(todo))
+	}
+	tm.nextID++
+
+	tm.todos = append(tm.todos, todo)
+	return tm.commit(fmt.Sprintf("add %q", todo.Text), before)
+}
+
+// ToggleComplete completes a todo with today's completion date, or
+// un-completes a completed one. A recurring todo spawns its next instance
+// when completed.
+func (tm *TodoManager) ToggleComplete(id int) error {
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			todo := tm.todos[i]
+			label := fmt.Sprintf("complete %q", todo.Text)
+			if todo.Completed {
+				label = fmt.Sprintf("uncomplete %q", todo.Text)
+			}
+
+			// Spawn the next instance of a recurring todo before its
+			// priority is moved into a pri: tag
+			next, recurs := Todo{}, false
+			if !todo.Completed {
+				next, recurs = nextRecurrence(todo, time.Now())
+			}
+
+			if todo.Completed {
+				// Un-completing puts the priority back in front
+				todo.Completed = false
+				todo.CompletionDate = ""
+				if priority, ok := todo.Tags["pri"]; ok && isPriority(priority) {
+					todo.Priority = priority
+					todo.Text = removeTag(todo.Text, "pri")
+				}
+			} else {
+				// The spec has no room for "(A)" on a completed line, so
+				// the priority is kept in a pri: tag instead
+				todo.Completed = true
+				todo.CompletionDate = time.Now().Format("2006-01-02")
+				if todo.Priority != "" {
+					todo.Text = setTag(todo.Text, "pri", todo.Priority)
+					todo.Priority = ""
+				}
+			}
+
+			tm.todos[i] = Parse(todo.ID, Format(todo))
+			if recurs {
+				tm.todos = append(tm.todos, Parse(tm.nextID, Format(next)))
+				tm.nextID++
+			}
+			return tm.commit(label, before)
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
+
+// DeleteTodo removes a todo. Like todo.sh, the line is left blank so the
+// other todos keep their IDs until the next archive, unless line numbers
+// aren't preserved.
+func (tm *TodoManager) DeleteTodo(id int) error {
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			label := fmt.Sprintf("delete %q", tm.todos[i].Text)
+			if tm.preserveLines {
+				tm.todos[i] = Todo{ID: id}
+				return tm.commit(label, before)
+			}
+
+			// The IDs stay as they were until the save, so a merge with
+			// outside changes still lines up with what was loaded
+			tm.todos = slices.Delete(tm.todos, i, i+1)
+			if err := tm.commit(label, before); err != nil {
+				return err
+			}
+			tm.setLines(tm.todos.Lines())
+			return nil
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
+
+// UpdateTodo replaces the text of a todo, keeping its completion,
+// priority and dates. Like AddTodo, it won't leave the todo empty.
+func (tm *TodoManager) UpdateTodo(id int, newText string) error {
+	newText = sanitizeInput(newText)
+	if newText == "" {
+		return ErrEmptyTodo
+	}
+
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			todo := tm.todos[i]
+			label := fmt.Sprintf("edit %q", todo.Text)
+
+			todo.Text = newText
+			tm.todos[i] = Parse(todo.ID, Format(todo))
+			return tm.commit(label, before)
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
+
+// Archive moves completed todos to the done list and rewrites the todo list,
+// the same way `todo.sh archive` does. It returns the number of archived todos.
+func (tm *TodoManager) Archive() (int, error) {
+	unlock, err := tm.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	// Archive what is on disk right now, so a failed merge can't leave
+	// the same todos in both files
+	if changed, err := tm.ChangedOnDisk(); err != nil {
+		return 0, err
+	} else if changed {
+		if err := tm.Load(); err != nil {
+			return 0, err
+		}
+	}
+
+	var archived []Todo
+	var remaining []Todo
+	for _, todo := range tm.todos {
+		switch {
+		case todo.Completed:
+			archived = append(archived, todo)
+		case !todo.IsBlank():
+			// Blank lines are dropped, as todo.sh does on archive
+			remaining = append(remaining, todo)
+		}
+	}
+
+	if len(archived) == 0 {
+		return 0, nil
+	}
+
+	if err := tm.appendDone(List(archived).Lines()); err != nil {
+		return 0, err
+	}
+
+	tm.todos = remaining
+	if err := tm.Save(); err != nil {
+		return 0, err
+	}
+
+	// Renumber the remaining todos like todo.sh does after an archive
+	if err := tm.Load(); err != nil {
+		return 0, err
+	}
+
+	// The archived lines now live in done.txt too, so undoing earlier
+	// changes would duplicate them
+	tm.history.reset(tm.todos.Lines())
+	return len(archived), nil
+}
+
+// appendDone adds lines to the end of the done list
+func (tm *TodoManager) appendDone(lines []string) error {
+	if store, ok := tm.done.(AppendStore); ok {
+		return store.Append(lines)
+	}
+	current, _, err := tm.done.Load()
+	if err != nil {
+		return err
+	}
+	_, err = tm.done.Save(append(current, lines...))
+	return err
+}
+
+// SetPriority gives a todo a priority from A to Z, or removes it for an
+// empty priority
+func (tm *TodoManager) SetPriority(id int, priority string) error {
+	if priority != "" && !isPriority(priority) {
+		return fmt.Errorf("%w %q", ErrInvalidPriority, priority)
+	}
+
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			todo := tm.todos[i]
+			label := fmt.Sprintf("priority %s on %q", priority, todo.Text)
+
+			if todo.Completed {
+				// Completed todos carry their priority in a pri: tag
+				todo.Text = removeTag(todo.Text, "pri")
+				todo.Priority = ""
+				if priority != "" {
+					todo.Text = setTag(todo.Text, "pri", priority)
+				}
+			} else {
+				todo.Priority = priority
+			}
+
+			tm.todos[i] = Parse(todo.ID, Format(todo))
+			return tm.commit(label, before)
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
+
+// GetTag returns the value of a todo's key:value tag
+func (tm *TodoManager) GetTag(id int, key string) (string, bool) {
+	for _, todo := range tm.todos {
+		if todo.ID == id && !todo.IsBlank() {
+			value, ok := todo.Tags[key]
+			return value, ok
+		}
+	}
+	return "", false
+}
+
+// SetTag adds or replaces a key:value tag on a todo
+func (tm *TodoManager) SetTag(id int, key, value string) error {
+	if _, _, ok := parseTag(key + ":" + value); !ok || strings.ContainsAny(key+value, " \t") {
+		return fmt.Errorf("%w %q", ErrInvalidTag, key+":"+value)
+	}
+
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			todo := tm.todos[i]
+			todo.Text = setTag(todo.Text, key, value)
+			tm.todos[i] = Parse(todo.ID, Format(todo))
+			return tm.commit(fmt.Sprintf("set %s:%s on %q", key, value, tm.todos[i].Text), before)
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
+
+// RemoveTag strips a key:value tag from a todo
+func (tm *TodoManager) RemoveTag(id int, key string) error {
+	for i := range tm.todos {
+		if tm.todos[i].ID == id && !tm.todos[i].IsBlank() {
+			before := tm.todos.Lines()
+			todo := tm.todos[i]
+			todo.Text = removeTag(todo.Text, key)
+			tm.todos[i] = Parse(todo.ID, Format(todo))
+			return tm.commit(fmt.Sprintf("remove %s: from %q", key, tm.todos[i].Text), before)
+		}
+	}
+	return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+}
@@ -0,0 +1,82 @@
+package todotxt
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newTestManager returns a TodoManager over a memory store holding lines
+func newTestManager(t *testing.T, lines ...string) (*TodoManager, *MemoryStore) {
+	t.Helper()
+	store := NewMemoryStore("todo.txt", lines...)
+	tm, err := NewTodoManagerWithStores(store, NewMemoryStore("done.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm, store
+}
+
+func TestBlankLinesAreNotFound(t *testing.T) {
+	tm, store := newTestManager(t, "first", "", "third")
+	if err := tm.DeleteTodo(1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "", "third"}
+
+	changes := map[string]func(id int) error{
+		"ToggleComplete": tm.ToggleComplete,
+		"DeleteTodo":     tm.DeleteTodo,
+		"UpdateTodo":     func(id int) error { return tm.UpdateTodo(id, "text") },
+		"SetPriority":    func(id int) error { return tm.SetPriority(id, "A") },
+		"SetTag":         func(id int) error { return tm.SetTag(id, "due", "2026-10-20") },
+		"RemoveTag":      func(id int) error { return tm.RemoveTag(id, "due") },
+	}
+	for name, change := range changes {
+		for _, id := range []int{1, 2} {
+			if err := change(id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("%s(%d) on a blank line = %v, want ErrNotFound", name, id, err)
+			}
+		}
+	}
+	if lines, _, _ := store.Load(); !slices.Equal(lines, want) {
+		t.Errorf("blank lines were written to: %q", lines)
+	}
+	if _, ok := tm.GetTag(1, "due"); ok {
+		t.Error("GetTag found a tag on a blank line")
+	}
+}
+
+func TestSetPriorityRejectsInvalidPriorities(t *testing.T) {
+	tm, store := newTestManager(t, "a", "(B) b")
+	for _, priority := range []string{"zz", "a", "1", "AB", " "} {
+		if err := tm.SetPriority(1, priority); !errors.Is(err, ErrInvalidPriority) {
+			t.Errorf("SetPriority(1, %q) = %v, want ErrInvalidPriority", priority, err)
+		}
+	}
+	if err := tm.SetPriority(1, "Z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.SetPriority(2, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"(Z) a", "b"}
+	if lines, _, _ := store.Load(); !slices.Equal(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestEmptyTodoTextIsAnError(t *testing.T) {
+	tm, store := newTestManager(t, "a")
+	for _, text := range []string{"", "   ", "\x1b[31m", "\x1b]0;title\x07", "\t\n"} {
+		if err := tm.AddTodo(text); !errors.Is(err, ErrEmptyTodo) {
+			t.Errorf("AddTodo(%q) = %v, want ErrEmptyTodo", text, err)
+		}
+		if err := tm.UpdateTodo(1, text); !errors.Is(err, ErrEmptyTodo) {
+			t.Errorf("UpdateTodo(1, %q) = %v, want ErrEmptyTodo", text, err)
+		}
+	}
+	if lines, _, _ := store.Load(); !slices.Equal(lines, []string{"a"}) {
+		t.Errorf("empty todos were written: %q", lines)
+	}
+}
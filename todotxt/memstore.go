@@ -0,0 +1,79 @@
+package todotxt
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryStore keeps a todo list in memory. It is handy for tests and for
+// tools that build a list before writing it anywhere.
+type MemoryStore struct {
+	mu       sync.Mutex
+	name     string
+	lines    []string
+	exists   bool
+	watchers []chan struct{}
+}
+
+// NewMemoryStore creates a MemoryStore holding the given lines. With no
+// lines the list starts out as not existing yet.
+func NewMemoryStore(name string, lines ...string) *MemoryStore {
+	return &MemoryStore{
+		name:   name,
+		lines:  slices.Clone(lines),
+		exists: len(lines) > 0,
+	}
+}
+
+// Load returns the lines held in memory
+func (s *MemoryStore) Load() ([]string, Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.exists {
+		return nil, "", nil
+	}
+	return slices.Clone(s.lines), versionOf(joinLines(s.lines, "\n")), nil
+}
+
+// Save replaces the lines held in memory
+func (s *MemoryStore) Save(lines []string) (Version, error) {
+	s.Set(lines)
+	return versionOf(joinLines(lines, "\n")), nil
+}
+
+// Set replaces the lines, as something else changing the list would, and
+// signals the watchers
+func (s *MemoryStore) Set(lines []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = slices.Clone(lines)
+	s.exists = true
+	for _, ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch signals whenever the lines are replaced
+func (s *MemoryStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers = append(s.watchers, ch)
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.watchers = slices.DeleteFunc(s.watchers, func(c chan struct{}) bool { return c == ch })
+	}()
+	return ch, nil
+}
+
+// Location returns the name the store was created with
+func (s *MemoryStore) Location() string {
+	return "memory:" + s.name
+}
@@ -0,0 +1,64 @@
+package todotxt
+
+// mergeTodos replays the changes made since the last load on top of the
+// lines currently on disk. base maps todo IDs (line numbers) to the lines
+// they were loaded from. Edited and blanked lines are matched by content in
+// theirs, and new todos are appended. If a line we changed was also changed or removed on
+// disk, ErrConflict is returned.
+func mergeTodos(base map[int]string, ours []Todo, theirs []string) ([]string, error) {
+	result := make([]string, len(theirs))
+	copy(result, theirs)
+	removed := make([]bool, len(result))
+
+	// find locates an untouched line in theirs, preferring the todo's old
+	// position since most external edits leave line order alone
+	find := func(id int, line string) int {
+		if i := id - 1; i >= 0 && i < len(result) && !removed[i] && result[i] == line {
+			return i
+		}
+		for i := range result {
+			if !removed[i] && result[i] == line {
+				return i
+			}
+		}
+		return -1
+	}
+
+	current := make(map[int]Todo, len(ours))
+	for _, todo := range ours {
+		current[todo.ID] = todo
+	}
+
+	for id, line := range base {
+		todo, kept := current[id]
+		if kept && todo.Raw == line {
+			continue
+		}
+
+		i := find(id, line)
+		if i < 0 {
+			return nil, ErrConflict
+		}
+		if kept {
+			result[i] = todo.Raw
+		} else {
+			removed[i] = true
+		}
+	}
+
+	merged := make([]string, 0, len(result))
+	for i, line := range result {
+		if !removed[i] {
+			merged = append(merged, line)
+		}
+	}
+	for _, todo := range ours {
+		if todo.IsBlank() {
+			continue
+		}
+		if _, known := base[todo.ID]; !known {
+			merged = append(merged, todo.Raw)
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,438 @@
+package todotxt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query is a parsed filter query such as
+//
+//	+work @office pri:A..B due<=today -done
+//
+// Terms next to each other must all match. OR (or |) matches either side,
+// NOT or a leading - negates a term, and parentheses group. A term is one
+// of:
+//
+//	+project @context    the todo has the project or context
+//	pri:A  pri:A..C      priority, a completed todo's pri: tag included
+//	due<=today           date comparison with <, <=, =, >= or >; the
+//	                     dates are due, t, created and completed
+//	done                 the todo is completed
+//	key:value  key:*     the todo has the tag, with any value for *
+//	word  "two words"    the text contains it, ignoring case
+//
+// Dates are YYYY-MM-DD, today, tomorrow, yesterday, or an offset from
+// today such as +3d, -1w, +2m or +1y.
+type Query struct {
+	source string
+	root   queryNode
+}
+
+// queryNode is one node of a parsed query
+type queryNode interface {
+	match(todo Todo, today time.Time) bool
+}
+
+// ParseQuery parses a filter query. An empty query matches every todo.
+func ParseQuery(source string) (*Query, error) {
+	tokens, err := tokenizeQuery(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	if len(tokens) == 0 {
+		return &Query{source: source}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidQuery, p.tokens[p.pos].text)
+	}
+	return &Query{source: source, root: root}, nil
+}
+
+// Match reports whether a todo matches the query. now decides what today
+// means for date comparisons.
+func (q *Query) Match(todo Todo, now time.Time) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+	return q.root.match(todo, startOfDay(now))
+}
+
+// String returns the query as it was written
+func (q *Query) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.source
+}
+
+// startOfDay returns midnight of the day t falls on, in local time
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// queryToken is a word, a quoted phrase or a parenthesis
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeQuery splits a query into tokens. Parentheses are tokens of
+// their own, and double quotes keep a phrase together.
+func tokenizeQuery(source string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t':
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		case r == '"' || (r == '-' && i+1 < len(runes) && runes[i+1] == '"'):
+			// A quoted phrase, possibly negated
+			negate := r == '-'
+			if negate {
+				i++
+			}
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: missing closing quote", ErrInvalidQuery)
+			}
+			if negate {
+				tokens = append(tokens, queryToken{text: "NOT"})
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !strings.ContainsRune(" \t()\"", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive descent parser over query tokens
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// peek returns the next token, or false at the end of the query
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// isOperator reports whether a token is the given unquoted keyword
+func (t queryToken) isOperator(names ...string) bool {
+	if t.quoted {
+		return false
+	}
+	for _, name := range names {
+		if t.text == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || !token.isOperator("OR", "|") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.isOperator("OR", "|", ")") {
+			return left, nil
+		}
+		if token.isOperator("AND") {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%w: query ends too early", ErrInvalidQuery)
+	}
+
+	if token.isOperator("NOT", "-") {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+
+	if token.isOperator("(") {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || !closing.isOperator(")") {
+			return nil, fmt.Errorf("%w: missing )", ErrInvalidQuery)
+		}
+		p.pos++
+		return node, nil
+	}
+
+	if token.isOperator(")", "OR", "|", "AND") {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidQuery, token.text)
+	}
+
+	p.pos++
+	if token.quoted {
+		return textNode(strings.ToLower(token.text)), nil
+	}
+	// A leading - negates a single term, as in -done or -@home
+	if rest, ok := strings.CutPrefix(token.text, "-"); ok && rest != "" {
+		node, err := parseTerm(rest)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return parseTerm(token.text)
+}
+
+// dateFields maps the date names a query can compare to the dates of a
+// todo
+var dateFields = map[string]func(Todo) (time.Time, bool){
+	"due":       Todo.DueDate,
+	"t":         Todo.ThresholdDate,
+	"created":   func(t Todo) (time.Time, bool) { return parseDate(t.CreatedDate) },
+	"completed": func(t Todo) (time.Time, bool) { return parseDate(t.CompletionDate) },
+}
+
+// comparisonRegex splits a date comparison such as due<=today
+var comparisonRegex = regexp.MustCompile(`^([a-z]+)(<=|>=|<|>|=|:)(.+)$`)
+
+// parseTerm parses a single query term
+func parseTerm(text string) (queryNode, error) {
+	switch {
+	case text == "done":
+		return doneNode{}, nil
+	case strings.HasPrefix(text, "+") && len(text) > 1:
+		return projectNode(strings.ToLower(text[1:])), nil
+	case strings.HasPrefix(text, "@") && len(text) > 1:
+		return contextNode(strings.ToLower(text[1:])), nil
+	}
+
+	if match := comparisonRegex.FindStringSubmatch(text); match != nil && match[3] != "*" {
+		field, op, value := match[1], match[2], match[3]
+		if dateOf, ok := dateFields[field]; ok {
+			date, err := parseQueryDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, text, err)
+			}
+			return dateNode{dateOf: dateOf, op: op, date: date}, nil
+		}
+		if field == "pri" && op == ":" {
+			return parsePriorityRange(text, value)
+		}
+		if op != ":" {
+			return nil, fmt.Errorf("%w: %s: only due, t, created and completed can be compared", ErrInvalidQuery, text)
+		}
+	}
+
+	if key, value, ok := strings.Cut(text, ":"); ok && key != "" && value != "" && !strings.HasPrefix(value, "//") {
+		return tagNode{key: key, value: strings.ToLower(value)}, nil
+	}
+	return textNode(strings.ToLower(text)), nil
+}
+
+// parsePriorityRange parses the value of pri:A or pri:A..C
+func parsePriorityRange(text, value string) (queryNode, error) {
+	from, to, isRange := strings.Cut(strings.ToUpper(value), "..")
+	if !isRange {
+		to = from
+	}
+	if !isPriority(from) || !isPriority(to) || from > to {
+		return nil, fmt.Errorf("%w: %s: priorities are A to Z, as in pri:A or pri:A..C", ErrInvalidQuery, text)
+	}
+	return priorityNode{from: from, to: to}, nil
+}
+
+// relativeDateRegex matches date offsets such as +3d or -1w
+var relativeDateRegex = regexp.MustCompile(`^([+-]\d+)([dwmy])$`)
+
+// queryDate is a date in a query. Dates relative to today are kept as
+// offsets, so a parsed query stays correct past midnight.
+type queryDate struct {
+	date   time.Time
+	amount int
+	unit   byte
+}
+
+// on returns the date the queryDate stands for on the given day
+func (d queryDate) on(today time.Time) time.Time {
+	switch d.unit {
+	case 'd':
+		return today.AddDate(0, 0, d.amount)
+	case 'w':
+		return today.AddDate(0, 0, 7*d.amount)
+	case 'm':
+		return addMonths(today, d.amount)
+	case 'y':
+		return addMonths(today, 12*d.amount)
+	}
+	return d.date
+}
+
+// parseQueryDate parses an absolute or relative date in a query
+func parseQueryDate(value string) (queryDate, error) {
+	switch value {
+	case "today":
+		return queryDate{unit: 'd'}, nil
+	case "tomorrow":
+		return queryDate{amount: 1, unit: 'd'}, nil
+	case "yesterday":
+		return queryDate{amount: -1, unit: 'd'}, nil
+	}
+	if match := relativeDateRegex.FindStringSubmatch(value); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+		return queryDate{amount: amount, unit: match[2][0]}, nil
+	}
+	if date, ok := parseDate(value); ok {
+		return queryDate{date: date}, nil
+	}
+	return queryDate{}, fmt.Errorf("%q isn't a date; use YYYY-MM-DD, today, tomorrow, yesterday or an offset like +3d", value)
+}
+
+// parseDate parses a YYYY-MM-DD date as a local date
+func parseDate(value string) (time.Time, bool) {
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	return date, err == nil
+}
+
+type (
+	andNode      struct{ left, right queryNode }
+	orNode       struct{ left, right queryNode }
+	notNode      struct{ node queryNode }
+	doneNode     struct{}
+	projectNode  string
+	contextNode  string
+	textNode     string
+	priorityNode struct{ from, to string }
+	tagNode      struct{ key, value string }
+	dateNode     struct {
+		dateOf func(Todo) (time.Time, bool)
+		op     string
+		date   queryDate
+	}
+)
+
+func (n andNode) match(todo Todo, today time.Time) bool {
+	return n.left.match(todo, today) && n.right.match(todo, today)
+}
+
+func (n orNode) match(todo Todo, today time.Time) bool {
+	return n.left.match(todo, today) || n.right.match(todo, today)
+}
+
+func (n notNode) match(todo Todo, today time.Time) bool {
+	return !n.node.match(todo, today)
+}
+
+func (doneNode) match(todo Todo, _ time.Time) bool {
+	return todo.Completed
+}
+
+func (n projectNode) match(todo Todo, _ time.Time) bool {
+	for _, project := range todo.Projects {
+		if strings.ToLower(project) == string(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n contextNode) match(todo Todo, _ time.Time) bool {
+	for _, context := range todo.Contexts {
+		if strings.ToLower(context) == string(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n textNode) match(todo Todo, _ time.Time) bool {
+	return strings.Contains(strings.ToLower(todo.Raw), string(n))
+}
+
+func (n priorityNode) match(todo Todo, _ time.Time) bool {
+	priority := todo.Priority
+	if priority == "" && todo.Completed {
+		priority = todo.Tags["pri"]
+	}
+	return priority != "" && priority >= n.from && priority <= n.to
+}
+
+func (n tagNode) match(todo Todo, _ time.Time) bool {
+	value, ok := todo.Tags[n.key]
+	return ok && (n.value == "*" || strings.ToLower(value) == n.value)
+}
+
+func (n dateNode) match(todo Todo, today time.Time) bool {
+	date, ok := n.dateOf(todo)
+	if !ok {
+		return false
+	}
+	other := n.date.on(today)
+	switch n.op {
+	case "<":
+		return date.Before(other)
+	case "<=":
+		return !date.After(other)
+	case ">":
+		return date.After(other)
+	case ">=":
+		return !date.Before(other)
+	}
+	return date.Equal(other)
+}
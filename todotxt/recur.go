@@ -0,0 +1,112 @@
+package todotxt
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// recurrenceRegex matches rec: values such as 1w, +3d or 2m
+var recurrenceRegex = regexp.MustCompile(`^(\+?)(\d+)([dbwmy])$`)
+
+// Recurrence describes a rec: tag. A strict recurrence ("+" prefix) is
+// scheduled from the old due and threshold dates, a normal one from the
+// day the todo was completed.
+type Recurrence struct {
+	Strict bool
+	Amount int
+	// Unit is one of d (days), b (business days), w (weeks), m (months)
+	// or y (years)
+	Unit byte
+}
+
+// ParseRecurrence parses the value of a rec: tag
+func ParseRecurrence(value string) (Recurrence, bool) {
+	match := recurrenceRegex.FindStringSubmatch(value)
+	if match == nil {
+		return Recurrence{}, false
+	}
+	amount, err := strconv.Atoi(match[2])
+	if err != nil || amount == 0 {
+		return Recurrence{}, false
+	}
+	return Recurrence{
+		Strict: match[1] == "+",
+		Amount: amount,
+		Unit:   match[3][0],
+	}, true
+}
+
+// Next returns the date one interval after from
+func (r Recurrence) Next(from time.Time) time.Time {
+	switch r.Unit {
+	case 'b':
+		date := from
+		for added := 0; added < r.Amount; {
+			date = date.AddDate(0, 0, 1)
+			if date.Weekday() != time.Saturday && date.Weekday() != time.Sunday {
+				added++
+			}
+		}
+		return date
+	case 'w':
+		return from.AddDate(0, 0, 7*r.Amount)
+	case 'm':
+		return addMonths(from, r.Amount)
+	case 'y':
+		return addMonths(from, 12*r.Amount)
+	default:
+		return from.AddDate(0, 0, r.Amount)
+	}
+}
+
+// addMonths adds months to a date, clamping to the end of shorter months
+// so that Jan 31 + 1m is Feb 28 rather than Mar 3
+func addMonths(from time.Time, months int) time.Time {
+	first := time.Date(from.Year(), from.Month()+time.Month(months), 1, 0, 0, 0, 0, from.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := from.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, from.Location())
+}
+
+// nextRecurrence builds the next instance of a recurring todo that is being
+// completed today, or reports false if the todo doesn't recur. The due: and
+// t: dates are moved forward one interval; a todo with neither gets a due:
+// date one interval from today.
+func nextRecurrence(todo Todo, now time.Time) (Todo, bool) {
+	rec, ok := ParseRecurrence(todo.Tags["rec"])
+	if !ok {
+		return Todo{}, false
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	next := Todo{
+		Priority: todo.Priority,
+		Text:     todo.Text,
+	}
+	if todo.CreatedDate != "" {
+		next.CreatedDate = today.Format("2006-01-02")
+	}
+
+	shifted := false
+	for _, key := range []string{"due", "t"} {
+		date, ok := todo.tagDate(key)
+		if !ok {
+			continue
+		}
+		base := today
+		if rec.Strict {
+			base = date
+		}
+		next.Text = setTag(next.Text, key, rec.Next(base).Format("2006-01-02"))
+		shifted = true
+	}
+	if !shifted {
+		next.Text = setTag(next.Text, "due", rec.Next(today).Format("2006-01-02"))
+	}
+
+	return next, true
+}
@@ -0,0 +1,101 @@
+package todotxt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// defaultStore is the storage backend used unless LAZYTODO_STORE says
+// otherwise
+const defaultStore = "file"
+
+// Version identifies one version of a stored todo list. The empty Version
+// means the list doesn't exist yet.
+type Version string
+
+// versionOf returns the Version of some todo.txt contents
+func versionOf(data []byte) Version {
+	sum := sha256.Sum256(data)
+	return Version(hex.EncodeToString(sum[:]))
+}
+
+// Store is where a todo list is kept. TodoManager reads and writes the list
+// only through a Store, so backends other than a local file can be plugged
+// in with RegisterStore.
+type Store interface {
+	// Load returns the lines of the list and the version they belong to.
+	// A list that doesn't exist yet loads as no lines and an empty Version.
+	Load() ([]string, Version, error)
+	// Save replaces the list and returns the version that was written
+	Save(lines []string) (Version, error)
+	// Watch sends on the returned channel when the list may have changed,
+	// until ctx is done. Spurious signals are fine; the receiver compares
+	// versions before reloading.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+	// Location describes where the list is kept, such as a file path
+	Location() string
+}
+
+// BackupStore is implemented by stores that keep backups of the list
+type BackupStore interface {
+	Backups() ([]Backup, error)
+	RestoreBackup(n int) error
+}
+
+// AppendStore is implemented by stores that can add lines to the end of
+// the list without rewriting it, which keeps archiving into a long done
+// file cheap
+type AppendStore interface {
+	Append(lines []string) error
+}
+
+// StoreFactory opens the store for a location such as a file path. backups
+// is how many backups the store should keep, if it supports them.
+type StoreFactory func(location string, backups int) (Store, error)
+
+var (
+	storesMu sync.RWMutex
+	stores   = map[string]StoreFactory{
+		"file": func(location string, backups int) (Store, error) {
+			return NewFileStore(location, backups), nil
+		},
+		"memory": func(location string, backups int) (Store, error) {
+			return NewMemoryStore(location), nil
+		},
+	}
+)
+
+// RegisterStore makes a storage backend available under name, for use with
+// the LAZYTODO_STORE config setting
+func RegisterStore(name string, factory StoreFactory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	stores[name] = factory
+}
+
+// OpenStore opens a location with the backend registered under name
+func OpenStore(name, location string, backups int) (Store, error) {
+	storesMu.RLock()
+	factory, ok := stores[name]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (available: %v)", ErrUnknownStore, name, StoreNames())
+	}
+	return factory(location, backups)
+}
+
+// StoreNames returns the names of the registered storage backends
+func StoreNames() []string {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
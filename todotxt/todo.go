@@ -0,0 +1,363 @@
+// Package todotxt reads and writes todo.txt files. It is the engine behind
+// lazytodo: parsing and formatting lines, tags, recurrence, and a
+// TodoManager that keeps a todo file in sync on disk with undo, backups
+// and merging of outside changes.
+package todotxt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// escapeSequenceRegex matches terminal escape sequences: CSI sequences
+// such as colors and cursor movement, OSC sequences such as window titles
+// and hyperlinks, and the remaining two-byte ESC sequences
+var escapeSequenceRegex = regexp.MustCompile(
+	`\x1b\[[0-?]*[ -/]*[@-~]` +
+		`|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)` +
+		`|\x1b[ -/]*[0-~]` +
+		`|\x{9b}[0-?]*[ -/]*[@-~]`,
+)
+
+// sanitizeInput cleans text typed or pasted into lazytodo before it is
+// stored. Only real escape sequences and control characters are removed;
+// tabs and ordinary text such as "run 5m" are kept as typed. Lines read
+// from the todo file are never sanitized, so they round-trip unchanged.
+func sanitizeInput(str string) string {
+	str = escapeSequenceRegex.ReplaceAllString(str, "")
+	str = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return r
+		case r == '\n' || r == '\r':
+			// A pasted newline would split the todo across two lines
+			return ' '
+		case r < 0x20 || (r >= 0x7f && r <= 0x9f):
+			return -1
+		}
+		return r
+	}, str)
+	return strings.TrimSpace(str)
+}
+
+// Todo is one line of a todo.txt file
+type Todo struct {
+	ID             int
+	Raw            string
+	Completed      bool
+	Priority       string
+	CompletionDate string
+	CreatedDate    string
+	Text           string
+	Projects       []string
+	Contexts       []string
+	// Tags holds key:value pairs such as due:2026-10-20. When a key
+	// appears more than once the first value wins.
+	Tags map[string]string
+}
+
+// List is the lines of a todo.txt file in order, blank lines included
+type List []Todo
+
+// ParseList parses the contents of a todo.txt file. IDs are line numbers,
+// blank lines included, matching `todo.sh ls`.
+func ParseList(data []byte) List {
+	return parseLines(splitLines(data))
+}
+
+// parseLines parses todo.txt lines into a List
+func parseLines(lines []string) List {
+	list := make(List, 0, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			list = append(list, Todo{ID: i + 1, Raw: line})
+		} else {
+			list = append(list, Parse(i+1, line))
+		}
+	}
+	return list
+}
+
+// Lines returns the raw lines of the list, blank lines included
+func (l List) Lines() []string {
+	lines := make([]string, 0, len(l))
+	for _, todo := range l {
+		lines = append(lines, todo.Raw)
+	}
+	return lines
+}
+
+// Bytes serializes the list as todo.txt contents, one line per todo
+func (l List) Bytes() []byte {
+	return joinLines(l.Lines(), "\n")
+}
+
+// Projects returns every project in the list once, sorted
+func (l List) Projects() []string {
+	return l.collect(func(t Todo) []string { return t.Projects })
+}
+
+// Contexts returns every context in the list once, sorted
+func (l List) Contexts() []string {
+	return l.collect(func(t Todo) []string { return t.Contexts })
+}
+
+// TagKeys returns the key of every tag in the list once, sorted
+func (l List) TagKeys() []string {
+	return l.collect(func(t Todo) []string {
+		keys := make([]string, 0, len(t.Tags))
+		for key := range t.Tags {
+			keys = append(keys, key)
+		}
+		return keys
+	})
+}
+
+// collect returns the distinct values of field over the list, sorted
+func (l List) collect(field func(Todo) []string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, todo := range l {
+		for _, value := range field(todo) {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// IsBlank reports whether the todo stands for a blank line in the file
+func (t Todo) IsBlank() bool {
+	return strings.TrimSpace(t.Raw) == ""
+}
+
+// DueDate returns the date from the todo's due: tag
+func (t Todo) DueDate() (time.Time, bool) {
+	return t.tagDate("due")
+}
+
+// ThresholdDate returns the date from the todo's t: tag, the day the
+// todo becomes relevant
+func (t Todo) ThresholdDate() (time.Time, bool) {
+	return t.tagDate("t")
+}
+
+// IsFuture reports whether the todo's threshold date is still ahead of now
+func (t Todo) IsFuture(now time.Time) bool {
+	threshold, ok := t.ThresholdDate()
+	if !ok {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return threshold.After(today)
+}
+
+// tagDate parses a YYYY-MM-DD tag value as a local date
+func (t Todo) tagDate(key string) (time.Time, bool) {
+	value, ok := t.Tags[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return parseDate(value)
+}
+
+// Parse parses one line of a todo.txt file. The line is kept in Raw as is,
+// so Format(Parse(id, line)) only differs from line once a field changes.
+func Parse(id int, line string) Todo {
+	todo := Todo{
+		ID:  id,
+		Raw: line,
+	}
+
+	text := line
+
+	dateRegex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}) `)
+
+	if strings.HasPrefix(text, "x ") {
+		todo.Completed = true
+		text = text[2:]
+
+		// The first date after "x " is the completion date
+		if match := dateRegex.FindStringSubmatch(text); match != nil {
+			todo.CompletionDate = match[1]
+			text = dateRegex.ReplaceAllString(text, "")
+		}
+	}
+
+	// Older lazytodo versions kept "(A)" after the "x ", so still accept it
+	priorityRegex := regexp.MustCompile(`^\(([A-Z])\) `)
+	if match := priorityRegex.FindStringSubmatch(text); match != nil {
+		todo.Priority = match[1]
+		text = priorityRegex.ReplaceAllString(text, "")
+	}
+
+	if match := dateRegex.FindStringSubmatch(text); match != nil {
+		todo.CreatedDate = match[1]
+		text = dateRegex.ReplaceAllString(text, "")
+	}
+
+	projectRegex := regexp.MustCompile(`\+([^\s]+)`)
+	projects := projectRegex.FindAllStringSubmatch(text, -1)
+	for _, project := range projects {
+		todo.Projects = append(todo.Projects, project[1])
+	}
+
+	contextRegex := regexp.MustCompile(`@([^\s]+)`)
+	contexts := contextRegex.FindAllStringSubmatch(text, -1)
+	for _, context := range contexts {
+		todo.Contexts = append(todo.Contexts, context[1])
+	}
+
+	todo.Tags = parseTags(text)
+
+	todo.Text = strings.TrimSpace(text)
+	return todo
+}
+
+// Format rebuilds the todo.txt line for a todo from its fields
+func Format(todo Todo) string {
+	text := todo.Text
+	prefix := ""
+	if todo.Completed {
+		prefix = "x "
+		switch {
+		case todo.CompletionDate != "":
+			prefix += todo.CompletionDate + " "
+		case todo.CreatedDate != "":
+			// A legacy "x (A) 2025-01-01 ..." line has a creation date but
+			// no completion date. A date right after "x " would read as the
+			// completion date, so the line keeps its priority in front.
+			priority := todo.Priority
+			if tag, ok := parseTags(text)["pri"]; ok && isPriority(tag) {
+				priority = tag
+				text = removeTag(text, "pri")
+			}
+			if priority != "" {
+				return fmt.Sprintf("x (%s) %s %s", priority, todo.CreatedDate, text)
+			}
+			// Without a priority there is nothing to keep the dates apart,
+			// so the creation date also stands in as the completion date
+			prefix += todo.CreatedDate + " "
+		}
+		// A legacy "x (A) ..." line keeps its priority as a tag
+		if todo.Priority != "" {
+			if _, ok := parseTags(text)["pri"]; !ok {
+				text = setTag(text, "pri", todo.Priority)
+			}
+		}
+	} else if todo.Priority != "" {
+		prefix += fmt.Sprintf("(%s) ", todo.Priority)
+	}
+	if todo.CreatedDate != "" {
+		prefix += todo.CreatedDate + " "
+	}
+	return prefix + text
+}
+
+// isPriority reports whether value is a single todo.txt priority letter
+func isPriority(value string) bool {
+	return len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z'
+}
+
+// parseTag splits a key:value word into its key and value. URLs such as
+// https://example.com are not tags.
+func parseTag(word string) (string, string, bool) {
+	key, value, found := strings.Cut(word, ":")
+	if !found || key == "" || value == "" {
+		return "", "", false
+	}
+	if strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+		return "", "", false
+	}
+	if strings.HasPrefix(key, "+") || strings.HasPrefix(key, "@") {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// parseTags collects the key:value tags in a todo's text
+func parseTags(text string) map[string]string {
+	tags := map[string]string{}
+	for _, word := range strings.Fields(text) {
+		if key, value, ok := parseTag(word); ok {
+			if _, exists := tags[key]; !exists {
+				tags[key] = value
+			}
+		}
+	}
+	return tags
+}
+
+// setTag sets key to value in the text. The first existing key: tag is
+// replaced in place, any duplicates are dropped, and a missing tag is
+// appended to the end.
+func setTag(text, key, value string) string {
+	words := strings.Split(text, " ")
+	kept := words[:0]
+	replaced := false
+	for _, word := range words {
+		if k, _, ok := parseTag(word); ok && k == key {
+			if replaced {
+				continue
+			}
+			word = key + ":" + value
+			replaced = true
+		}
+		kept = append(kept, word)
+	}
+	text = strings.Join(kept, " ")
+	if !replaced {
+		text = strings.TrimSpace(text) + " " + key + ":" + value
+	}
+	return strings.TrimSpace(text)
+}
+
+// removeTag strips every key: tag from the text
+func removeTag(text, key string) string {
+	words := strings.Split(text, " ")
+	kept := words[:0]
+	for _, word := range words {
+		if k, _, ok := parseTag(word); ok && k == key {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	return strings.TrimSpace(strings.Join(kept, " "))
+}
+
+// splitLines splits file contents into lines, dropping line endings
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// lineEnding returns the line ending a file uses, so saves don't turn a
+// CRLF file into an LF one
+func lineEnding(data []byte) string {
+	if bytes.Contains(data, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// joinLines joins lines into file contents, ending every line with newline
+func joinLines(lines []string, newline string) []byte {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line + newline)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,151 @@
+package todotxt
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// roundTripLines are real todo.txt lines that must come back byte for
+// byte after being parsed and saved
+var roundTripLines = []string{
+	"run 5m",
+	"buy 2m cable",
+	"10;20m",
+	"(A) call mom",
+	"(B) 2026-10-01 water plants +home @garden",
+	"2026-10-01 no priority, created date only",
+	"x 2026-10-18 2026-10-01 done with both dates",
+	"x 2026-10-18 done with a completion date",
+	"x done without dates",
+	"x 2026-10-18 2026-10-01 finished report +work pri:A",
+	"x (A) 2025-01-01 legacy completed line",
+	"pay rent due:2026-11-01 t:2026-10-25 rec:+1m",
+	"tabs\tbetween\twords",
+	"\tleading tab",
+	"trailing spaces  ",
+	"  leading spaces",
+	"double  spaced   words",
+	"url http://example.com/a?b=c&d=e:f",
+	"unicode ✓ café 日本語 @café",
+	"(a) lowercase priority is plain text",
+	"x2026-10-18 not completed without the space",
+	"[31m looks like a color code but has no escape",
+	"",
+	"   ",
+}
+
+func TestParseListRoundTrip(t *testing.T) {
+	for _, line := range roundTripLines {
+		data := []byte(line + "\n")
+		if got := ParseList(data).Bytes(); string(got) != string(data) {
+			t.Errorf("ParseList(%q).Bytes() = %q", data, got)
+		}
+	}
+
+	// The whole corpus as one file, blank lines included
+	data := []byte(strings.Join(roundTripLines, "\n") + "\n")
+	if got := ParseList(data).Bytes(); string(got) != string(data) {
+		t.Errorf("corpus round trip changed the file:\n%q\n%q", data, got)
+	}
+}
+
+func TestSaveKeepsUntouchedLines(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	for _, newline := range []string{"\n", "\r\n"} {
+		path := filepath.Join(t.TempDir(), "todo.txt")
+		data := strings.Join(roundTripLines, newline) + newline
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		tm, err := NewTodoManagerWithStores(NewFileStore(path, 0), NewMemoryStore("done.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm.SetDateOnAdd(false)
+		if err := tm.AddTodo("new todo"); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := data + "new todo" + newline; string(got) != want {
+			t.Errorf("save with %q line endings:\n got %q\nwant %q", newline, got, want)
+		}
+	}
+}
+
+func TestSanitizeInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"run 5m", "run 5m"},
+		{"buy 2m cable", "buy 2m cable"},
+		{"10;20m", "10;20m"},
+		{"[31m no escape", "[31m no escape"},
+		{"tabs\tkept", "tabs\tkept"},
+		{"\x1b[31mred\x1b[0m text", "red text"},
+		{"\x1b[1;32mbold green\x1b[m", "bold green"},
+		{"cursor\x1b[2Kcleared", "cursorcleared"},
+		{"\x1b]0;window title\x07run 5m", "run 5m"},
+		{"\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"pasted\nline", "pasted line"},
+		{"bell\x07 gone", "bell gone"},
+		{"  trimmed  ", "trimmed"},
+	}
+	for _, test := range tests {
+		if got := sanitizeInput(test.input); got != test.want {
+			t.Errorf("sanitizeInput(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseListIDsAreLineNumbers(t *testing.T) {
+	list := ParseList([]byte("first\n\nthird\n"))
+	var ids []int
+	for _, todo := range list {
+		ids = append(ids, todo.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 3}) || !list[1].IsBlank() {
+		t.Errorf("IDs = %v, want line numbers 1 to 3 with 2 blank", ids)
+	}
+}
+
+func TestLegacyCompletedLineKeepsCreationDate(t *testing.T) {
+	tm, store := newTestManager(t, "x (A) 2025-01-01 legacy")
+	lines := func() string {
+		lines, _, _ := store.Load()
+		return lines[0]
+	}
+
+	if got := Format(Parse(1, "x (A) 2025-01-01 legacy")); got != "x (A) 2025-01-01 legacy" {
+		t.Errorf("Format = %q", got)
+	}
+
+	steps := []struct {
+		change func() error
+		want   string
+	}{
+		{func() error { return tm.SetTag(1, "due", "2025-02-01") }, "x (A) 2025-01-01 legacy due:2025-02-01"},
+		{func() error { return tm.SetPriority(1, "B") }, "x (B) 2025-01-01 legacy due:2025-02-01"},
+		{func() error { return tm.UpdateTodo(1, "legacy edited") }, "x (B) 2025-01-01 legacy edited"},
+		{func() error { return tm.ToggleComplete(1) }, "(B) 2025-01-01 legacy edited"},
+	}
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatal(err)
+		}
+		if got := lines(); got != step.want {
+			t.Errorf("got %q, want %q", got, step.want)
+		}
+		if todo := Parse(1, lines()); todo.CreatedDate != "2025-01-01" || todo.CompletionDate != "" {
+			t.Errorf("%q parses with created %q, completed %q", lines(), todo.CreatedDate, todo.CompletionDate)
+		}
+	}
+}